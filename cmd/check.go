@@ -0,0 +1,484 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/relardev/go-pattern-implement/internal/diff"
+	"github.com/relardev/go-pattern-implement/internal/generator"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const programName = "go-pattern-implement"
+
+var checkCmd = &cobra.Command{
+	Use:   "check [dir...]",
+	Short: "Check generated files are up to date",
+	Long: `Check generated files are up to date. Every file written with --out
+is generated again in memory and compared with the one on disk, commands
+are taken from go:generate directives, headers of generated files and the
+manifest. Directories are searched recursively, current one by default.
+
+Manifest lists commands one per line, as they would be typed in the
+directory of the manifest, lines starting with # are ignored:
+
+	go-pattern-implement implement mock --source ./storage --type Repo --out storage/mock.go
+	`,
+	Run: func(cmd *cobra.Command, args []string) {
+		manifest, err := cmd.Flags().GetString("manifest")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		dirs := args
+		if len(dirs) == 0 && manifest == "" {
+			dirs = []string{"."}
+		}
+
+		generations := []generation{}
+		if manifest != "" {
+			generations = append(generations, fromManifest(manifest)...)
+		}
+
+		headers := []header{}
+		for _, dir := range dirs {
+			// directories are searched recursively anyway, accept patterns
+			// like "./..." known from go tool
+			dir = strings.TrimSuffix(dir, "...")
+			if dir == "" {
+				dir = "."
+			}
+
+			found, foundHeaders := fromDir(dir)
+			generations = append(generations, found...)
+			headers = append(headers, foundHeaders...)
+		}
+
+		// files generated without directive or manifest entry are
+		// recognized by their headers
+		covered := map[string]bool{}
+		for _, g := range generations {
+			covered[g.out] = true
+		}
+
+		for _, h := range headers {
+			if covered[h.path] {
+				continue
+			}
+
+			if g, ok := fromHeader(h); ok {
+				generations = append(generations, g)
+			}
+		}
+
+		generations = unique(generations)
+
+		outdated, checked := 0, 0
+		for _, g := range generations {
+			flags := g.flags()
+
+			// regenerating would read the checker's own stdin
+			if !hasInputFlag(flags) {
+				fmt.Fprintf(os.Stderr, "%s: skipped, the interface was read from stdin, "+
+					"generate the file with --source or --file to check it\n", g.origin)
+				continue
+			}
+
+			checked++
+			if difference := g.check(flags); difference != "" {
+				fmt.Print(difference)
+				outdated++
+			}
+		}
+
+		if outdated > 0 {
+			fmt.Fprintf(os.Stderr, "%d of %d generated files are out of date\n", outdated, checked)
+			os.Exit(1)
+		}
+
+		fmt.Printf("%d generated files are up to date\n", checked)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+	checkCmd.Flags().
+		StringP("manifest", "m", "", "file listing commands generating files")
+}
+
+// generation is a recorded run of implement command writing a file
+type generation struct {
+	// dir is the directory the command runs in
+	dir string
+	// args follow the program name
+	args []string
+	// out is the absolute path of generated file
+	out string
+	// goPackage is set for commands from go:generate directives
+	goPackage string
+	// origin tells where the command was found
+	origin string
+}
+
+// header is the command recorded in the generated file
+type header struct {
+	path    string
+	command string
+	origin  string
+}
+
+// flags parses the recorded arguments with flags of implement command
+func (g generation) flags() *pflag.FlagSet {
+	flags := pflag.NewFlagSet("implement", pflag.ContinueOnError)
+	addInputFlags(flags)
+	addImplementFlags(flags)
+
+	if len(g.args) == 0 || g.args[0] != "implement" {
+		log.Fatalf("%s: expected implement command", g.origin)
+	}
+
+	if err := flags.Parse(g.args[1:]); err != nil {
+		log.Fatalf("%s: %s", g.origin, err)
+	}
+
+	if flags.NArg() != 1 {
+		log.Fatalf("%s: expected exactly one implementation", g.origin)
+	}
+
+	return flags
+}
+
+// check generates the file again and returns its differences from the one
+// on disk
+func (g generation) check(flags *pflag.FlagSet) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := os.Chdir(g.dir); err != nil {
+		log.Fatal(err)
+	}
+
+	previousPackage, hadPackage := os.LookupEnv("GOPACKAGE")
+	if g.goPackage != "" {
+		os.Setenv("GOPACKAGE", g.goPackage)
+	}
+
+	generated := implementFile(flags, flags.Arg(0), append([]string{programName}, g.args...))
+
+	if hadPackage {
+		os.Setenv("GOPACKAGE", previousPackage)
+	} else {
+		os.Unsetenv("GOPACKAGE")
+	}
+
+	if err := os.Chdir(wd); err != nil {
+		log.Fatal(err)
+	}
+
+	existing, err := os.ReadFile(g.out)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		log.Fatal(err)
+	}
+
+	name := g.out
+	if relative, err := filepath.Rel(wd, g.out); err == nil {
+		name = relative
+	}
+
+	return diff.Unified(name, name+" (generated)", string(existing), string(generated))
+}
+
+// fromDir finds go:generate directives and headers of generated files in
+// the directory and its subdirectories, skipping the ones go tool skips
+func fromDir(root string) ([]generation, []header) {
+	generations := []generation{}
+	headers := []header{}
+
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != root && (strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") ||
+				name == "testdata" || name == "vendor") {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !strings.HasSuffix(name, ".go") {
+			return nil
+		}
+
+		found, fileHeader := fromFile(path)
+		generations = append(generations, found...)
+		if fileHeader != nil {
+			headers = append(headers, *fileHeader)
+		}
+
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return generations, headers
+}
+
+// fromFile returns generations from go:generate directives of the file and
+// the header if the file was generated
+func fromFile(path string) ([]generation, *header) {
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir := filepath.Dir(absPath)
+
+	generations := []generation{}
+	var fileHeader *header
+	goPackage := ""
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		origin := fmt.Sprintf("%s:%d", path, n)
+
+		if n == 1 && line == generator.GeneratedHeader && scanner.Scan() {
+			n++
+			fileHeader = &header{
+				path:    absPath,
+				command: strings.TrimPrefix(scanner.Text(), "// "),
+				origin:  origin,
+			}
+
+			continue
+		}
+
+		if goPackage == "" && strings.HasPrefix(line, "package ") {
+			goPackage = strings.TrimSpace(strings.TrimPrefix(line, "package "))
+		}
+
+		directive, ok := strings.CutPrefix(line, "//go:generate ")
+		if !ok {
+			continue
+		}
+
+		words := expandDirective(splitCommand(directive), filepath.Base(path), goPackage)
+		if g, ok := fromCommand(dir, words, origin); ok {
+			g.goPackage = goPackage
+			generations = append(generations, g)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	return generations, fileHeader
+}
+
+// fromHeader recovers the directory the command was run in from the
+// relative output path
+func fromHeader(h header) (generation, bool) {
+	path, origin := h.path, h.origin
+	words := splitCommand(h.command)
+
+	out := outPath(programArgs(words))
+	if out == "" {
+		return generation{}, false
+	}
+
+	if filepath.IsAbs(out) {
+		return fromCommand(filepath.Dir(path), words, origin)
+	}
+
+	out = filepath.Clean(out)
+	dir, ok := strings.CutSuffix(path, string(filepath.Separator)+out)
+	if !ok || strings.HasPrefix(out, "..") {
+		fmt.Fprintf(os.Stderr, "%s: can't tell where the file was generated from, "+
+			"add it to a go:generate directive or the manifest\n", origin)
+		return generation{}, false
+	}
+
+	return fromCommand(dir, words, origin)
+}
+
+func fromManifest(manifest string) []generation {
+	content, err := os.ReadFile(manifest)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(manifest))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	generations := []generation{}
+	for n, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		origin := fmt.Sprintf("%s:%d", manifest, n+1)
+
+		g, ok := fromCommand(dir, splitCommand(line), origin)
+		if !ok {
+			log.Fatalf("%s: expected %s command writing a file with --out", origin, programName)
+		}
+
+		generations = append(generations, g)
+	}
+
+	return generations
+}
+
+// fromCommand makes generation of the command if it runs this program and
+// writes a file
+func fromCommand(dir string, words []string, origin string) (generation, bool) {
+	args := programArgs(words)
+
+	out := outPath(args)
+	if out == "" {
+		return generation{}, false
+	}
+
+	if !filepath.IsAbs(out) {
+		out = filepath.Join(dir, out)
+	}
+
+	return generation{
+		dir:    dir,
+		args:   args,
+		out:    out,
+		origin: origin,
+	}, true
+}
+
+// programArgs returns arguments following this program, run directly or
+// with go run, nil for commands running something else
+func programArgs(words []string) []string {
+	switch {
+	case len(words) > 0 && filepath.Base(words[0]) == programName:
+		return words[1:]
+	case len(words) > 2 && words[0] == "go" && words[1] == "run" &&
+		strings.Contains(words[2], programName):
+		return words[3:]
+	default:
+		return nil
+	}
+}
+
+// outPath returns value of --out flag, empty string if there is none
+func outPath(args []string) string {
+	for n, arg := range args {
+		for _, flag := range []string{"--out", "-o"} {
+			if value, ok := strings.CutPrefix(arg, flag+"="); ok {
+				return value
+			}
+
+			if arg == flag && n+1 < len(args) {
+				return args[n+1]
+			}
+		}
+	}
+
+	return ""
+}
+
+// splitCommand splits the command into words on spaces, double quoted
+// strings are one word, the way go generate does it
+func splitCommand(command string) []string {
+	words := []string{}
+
+	for rest := strings.TrimSpace(command); rest != ""; rest = strings.TrimSpace(rest) {
+		if rest[0] != '"' {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+
+			words = append(words, rest[:end])
+			rest = rest[end:]
+
+			continue
+		}
+
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			log.Fatalf("unterminated quoted string in: %s", command)
+		}
+
+		word, err := strconv.Unquote(quoted)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		words = append(words, word)
+		rest = rest[len(quoted):]
+	}
+
+	return words
+}
+
+// expandDirective expands environment variables the way go generate does,
+// with the variables it sets for the file
+func expandDirective(words []string, goFile, goPackage string) []string {
+	expanded := make([]string, 0, len(words))
+	for _, word := range words {
+		expanded = append(expanded, os.Expand(word, func(name string) string {
+			switch name {
+			case "GOFILE":
+				return goFile
+			case "GOPACKAGE":
+				return goPackage
+			case "DOLLAR":
+				return "$"
+			default:
+				return os.Getenv(name)
+			}
+		}))
+	}
+
+	return expanded
+}
+
+// unique drops generations of the same file found again, the first one wins,
+// so directives are preferred over headers
+func unique(generations []generation) []generation {
+	seen := map[string]bool{}
+	result := []generation{}
+
+	for _, g := range generations {
+		if seen[g.out] {
+			continue
+		}
+
+		seen[g.out] = true
+		result = append(result, g)
+	}
+
+	return result
+}
@@ -2,53 +2,336 @@ package cmd
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
+	"go/parser"
+	"go/token"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/relardev/go-pattern-implement/internal/generator"
+	"github.com/relardev/go-pattern-implement/internal/source"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var implementCmd = &cobra.Command{
 	Use:   "implement <implementation>",
 	Short: "Implemen an interface",
-	Long: `Implemen an interface. This command will read stdin or file
-and generate the implementation on stdout
+	Long: `Implemen an interface. This command will read stdin or file,
+or load the interface from a package with --source and --type,
+and generate the implementation on stdout, or add what is missing
+to the file written before with --update
 
 	to find out available implementations, run:
 	$ pattern-implement list
 	`,
 	Args: cobra.ExactArgs(1),
 	Run: func(cmd *cobra.Command, args []string) {
-		packageName, err := cmd.Flags().GetString("package")
+		implementation := args[0]
+
+		out, err := cmd.Flags().GetString("out")
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		filePath, err := cmd.Flags().GetString("file")
+		emitFile, err := cmd.Flags().GetBool("emit-file")
 		if err != nil {
 			log.Fatal(err)
 		}
 
-		input := getInput(filePath)
+		update, err := cmd.Flags().GetString("update")
+		if err != nil {
+			log.Fatal(err)
+		}
 
-		implementation := args[0]
-		g := generator.NewGenerator(true)
-		g.Implement(input, implementation, packageName)
+		if update != "" {
+			updateFile(cmd.Flags(), implementation, update)
+			return
+		}
+
+		if out == "" && !emitFile {
+			loaded, packageName := loadInputWithPackage(cmd.Flags())
+			g := generator.NewGenerator(true)
+			g.Implement(loaded.Input, implementation, packageName)
+			return
+		}
+
+		var command []string
+		if out != "" {
+			// the header records the command, it has to find the input again
+			if !hasInputFlag(cmd.Flags()) {
+				log.Fatal("--out needs the interface from --source or --file, stdin can't be read again when regenerating")
+			}
+
+			command = append([]string{"go-pattern-implement"}, os.Args[1:]...)
+		}
+
+		file := implementFile(cmd.Flags(), implementation, command)
+
+		if out == "" {
+			if _, err := os.Stdout.Write(file); err != nil {
+				log.Fatal(err)
+			}
+
+			return
+		}
+
+		if err := os.MkdirAll(filepath.Dir(out), 0o755); err != nil {
+			log.Fatal(err)
+		}
+
+		if err := os.WriteFile(out, file, 0o644); err != nil {
+			log.Fatal(err)
+		}
 	},
 }
 
+// updateFile adds what is missing in the file written by the tool before,
+// keeping changes made to it by hand
+func updateFile(flags *pflag.FlagSet, implementation, path string) {
+	removeStale, err := flags.GetBool("remove-stale")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	outPackage, err := flags.GetString("out-package")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if outPackage == "" {
+		parsed, err := parser.ParseFile(token.NewFileSet(), path, existing, parser.PackageClauseOnly)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if err := flags.Set("out-package", parsed.Name.Name); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	generated := implementFile(flags, implementation, nil)
+
+	result, err := generator.Update(existing, generated, removeStale)
+	if err != nil {
+		log.Fatalf("%s: %s", path, err)
+	}
+
+	for _, added := range result.Added {
+		fmt.Fprintf(os.Stderr, "%s: added %s\n", path, added)
+	}
+
+	for _, regenerated := range result.Regenerated {
+		fmt.Fprintf(os.Stderr, "%s: regenerated %s, fields it initializes changed\n", path, regenerated)
+	}
+
+	for _, stale := range result.Stale {
+		if removeStale {
+			fmt.Fprintf(os.Stderr, "%s: removed %s, not on the interface anymore\n", path, stale)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: %s is not on the interface anymore, "+
+				"remove it or run with --remove-stale\n", path, stale)
+		}
+	}
+
+	if bytes.Equal(existing, result.Source) {
+		return
+	}
+
+	if err := os.WriteFile(path, result.Source, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// implementFile generates complete file as configured by the flags, command
+// goes into the generated code header
+func implementFile(flags *pflag.FlagSet, implementation string, command []string) []byte {
+	loaded, packageName := loadInputWithPackage(flags)
+
+	out, err := flags.GetString("out")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if out == "" {
+		// updated file is where the code goes as well
+		out, err = flags.GetString("update")
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	samePackage := false
+	if out != "" && loaded.Dir != "" {
+		outDir, err := filepath.Abs(filepath.Dir(out))
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		samePackage = outDir == loaded.Dir
+	}
+
+	outPackage, err := flags.GetString("out-package")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if outPackage == "" && samePackage {
+		outPackage = loaded.PackageName
+	}
+
+	if outPackage == "" && out != "" {
+		outPackage = packageInDir(filepath.Dir(out))
+	}
+
+	if outPackage == "" && out != "" && filepath.Dir(out) != "." {
+		outPackage = filepath.Base(filepath.Dir(out))
+		if !token.IsIdentifier(outPackage) {
+			log.Fatalf("%s directory of --out is not a valid package name, set one with --out-package", outPackage)
+		}
+	}
+
+	if outPackage == "" {
+		// set by go generate, for the package of the directive
+		outPackage = os.Getenv("GOPACKAGE")
+	}
+
+	if outPackage == "" {
+		log.Fatal("--out-package is required when it can't be told from --out directory")
+	}
+
+	importPath, err := flags.GetString("import-path")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if importPath == "" {
+		importPath = loaded.ImportPath
+	}
+
+	g := generator.NewGenerator(false)
+
+	return g.ImplementFile(loaded.Input, implementation, packageName, generator.FileOptions{
+		Package:     outPackage,
+		ImportPath:  importPath,
+		SamePackage: samePackage,
+		Command:     command,
+	})
+}
+
+// packageInDir returns name of the package of Go files in the directory,
+// empty string if there are none
+func packageInDir(dir string) string {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+
+		parsed, err := parser.ParseFile(token.NewFileSet(), path, nil, parser.PackageClauseOnly)
+		if err != nil {
+			continue
+		}
+
+		return parsed.Name.Name
+	}
+
+	return ""
+}
+
+// loadInputWithPackage loads the input along with name of the package to
+// qualify its types with
+func loadInputWithPackage(flags *pflag.FlagSet) (*source.Interface, string) {
+	packageName, err := flags.GetString("package")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loaded := loadInput(flags)
+	if packageName == "" {
+		packageName = loaded.PackageName
+	}
+
+	if packageName == "" {
+		log.Fatal("--package is required unless the interface is loaded with --source")
+	}
+
+	return loaded, packageName
+}
+
 func init() {
 	rootCmd.AddCommand(implementCmd)
-	implementCmd.Flags().
-		StringP("package", "p", "", "package from which the interface comes from")
-	err := implementCmd.MarkFlagRequired("package")
+	addImplementFlags(implementCmd.Flags())
+	implementCmd.MarkFlagsMutuallyExclusive("update", "out")
+	implementCmd.MarkFlagsMutuallyExclusive("update", "emit-file")
+}
+
+// addImplementFlags defines flags of implement command, check command
+// parses recorded commands with them as well
+func addImplementFlags(flags *pflag.FlagSet) {
+	flags.
+		StringP("package", "p", "", "package from which the interface comes from, defaults to the --source package name")
+	flags.
+		Bool("emit-file", false, "output a complete file with package clause and imports")
+	flags.
+		String("out-package", "", "package name of the emitted file")
+	flags.
+		String("import-path", "", "import path of the package the interface comes from, defaults to the --source package path")
+	flags.
+		StringP("out", "o", "", "write complete file marked as generated to the path, for go:generate")
+	flags.
+		String("update", "", "add methods missing in the file written before, keeping changes made to it")
+	flags.
+		Bool("remove-stale", false, "with --update, remove methods not on the interface anymore")
+}
+
+// hasInputFlag tells whether the input is read from --source or --file
+// rather than stdin
+func hasInputFlag(flags *pflag.FlagSet) bool {
+	return flags.Changed("source") || flags.Changed("file")
+}
+
+// loadInput returns the interface declaration from --source package, or
+// read from file or stdin, without package name and import path then
+func loadInput(flags *pflag.FlagSet) *source.Interface {
+	sourcePath, err := flags.GetString("source")
 	if err != nil {
 		log.Fatal(err)
 	}
+
+	if sourcePath == "" {
+		filePath, err := flags.GetString("file")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		return &source.Interface{Input: getInput(filePath)}
+	}
+
+	typeName, err := flags.GetString("type")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	loaded, err := source.Load(sourcePath, typeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return loaded
 }
 
 func getInput(filePath string) string {
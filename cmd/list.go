@@ -21,18 +21,11 @@ var listCmd = &cobra.Command{
 			log.Fatal(err)
 		}
 
-		filePath, err := cmd.Flags().GetString("file")
-		if err != nil {
-			log.Fatal(err)
-		}
-
 		var list []string
 
 		g := generator.NewGenerator(false)
 		if available {
-			list, err = g.ListAvailableImplementators(
-				getInput(filePath),
-			)
+			list, err = g.ListAvailableImplementators(loadInput(cmd.Flags()).Input)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -4,6 +4,7 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 var rootCmd = &cobra.Command{
@@ -22,6 +23,17 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().
+	addInputFlags(rootCmd.PersistentFlags())
+	rootCmd.MarkFlagsRequiredTogether("source", "type")
+	rootCmd.MarkFlagsMutuallyExclusive("source", "file")
+}
+
+// addInputFlags defines flags telling where the interface comes from
+func addInputFlags(flags *pflag.FlagSet) {
+	flags.
 		StringP("file", "f", "", "path to file with interface to implement")
+	flags.
+		StringP("source", "s", "", "directory or import path of package with interface to implement")
+	flags.
+		StringP("type", "t", "", "name of the interface to implement from --source package")
 }
@@ -50,7 +50,7 @@ func FieldFromTypeSpec(typeSpec *ast.TypeSpec, packageName string) StructField {
 	lowerFirstLetter := unicode.ToLower(rune(name[0]))
 	return StructField{
 		Name:    string(lowerFirstLetter),
-		TypeStr: packageName + "." + name,
+		TypeStr: packageName + "." + name + TypeArgs(typeSpec.TypeParams),
 	}
 }
 
@@ -101,12 +101,26 @@ func AddPackageNameToFieldListAndRemoveNames(fl *ast.FieldList, packageName stri
 	return fl
 }
 
+// AddPackageNameToFieldList qualifies types in the FieldList, keeping the names
+func AddPackageNameToFieldList(fl *ast.FieldList, packageName string) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+
+	for _, r := range fl.List {
+		r.Type = PossiblyAddPackageName(packageName, r.Type)
+	}
+
+	return fl
+}
+
+// PossiblyAddPackageName qualifies types of the source package used in the
+// expression, builtins, type parameters and selectors are left as they are
 func PossiblyAddPackageName(packageName string, expr ast.Expr) ast.Expr {
 	var newExpr ast.Expr
 	switch t := expr.(type) {
 	case *ast.Ident:
-		isFirstLetterUpper := unicode.IsUpper(rune(t.Name[0]))
-		if isFirstLetterUpper {
+		if needsPackageName(t) {
 			newExpr = &ast.SelectorExpr{
 				X:   ast.NewIdent(packageName),
 				Sel: t,
@@ -119,11 +133,17 @@ func PossiblyAddPackageName(packageName string, expr ast.Expr) ast.Expr {
 			X: PossiblyAddPackageName(packageName, t.X),
 		}
 	case *ast.ArrayType:
+		var length ast.Expr
+		if t.Len != nil {
+			length = PossiblyAddPackageName(packageName, t.Len)
+		}
+
 		newExpr = &ast.ArrayType{
+			Len: length,
 			Elt: PossiblyAddPackageName(packageName, t.Elt),
 		}
 
-	case *ast.SelectorExpr:
+	case *ast.SelectorExpr, *ast.BasicLit:
 		return t
 
 	case *ast.MapType:
@@ -132,8 +152,62 @@ func PossiblyAddPackageName(packageName string, expr ast.Expr) ast.Expr {
 			Value: PossiblyAddPackageName(packageName, t.Value),
 		}
 
+	case *ast.ChanType:
+		newExpr = &ast.ChanType{
+			Dir:   t.Dir,
+			Value: PossiblyAddPackageName(packageName, t.Value),
+		}
+	case *ast.FuncType:
+		newExpr = &ast.FuncType{
+			TypeParams: t.TypeParams,
+			Params:     copyFieldList(packageName, t.Params),
+			Results:    copyFieldList(packageName, t.Results),
+		}
+	case *ast.StructType:
+		newExpr = &ast.StructType{
+			Fields: copyFieldList(packageName, t.Fields),
+		}
 	case *ast.InterfaceType:
-		newExpr = t
+		newExpr = &ast.InterfaceType{
+			Methods: copyFieldList(packageName, t.Methods),
+		}
+	case *ast.ParenExpr:
+		newExpr = &ast.ParenExpr{
+			X: PossiblyAddPackageName(packageName, t.X),
+		}
+	case *ast.Ellipsis:
+		newExpr = &ast.Ellipsis{
+			Elt: PossiblyAddPackageName(packageName, t.Elt),
+		}
+	case *ast.IndexExpr:
+		newExpr = &ast.IndexExpr{
+			X:     PossiblyAddPackageName(packageName, t.X),
+			Index: PossiblyAddPackageName(packageName, t.Index),
+		}
+	case *ast.IndexListExpr:
+		indices := make([]ast.Expr, 0, len(t.Indices))
+		for _, index := range t.Indices {
+			indices = append(indices, PossiblyAddPackageName(packageName, index))
+		}
+
+		newExpr = &ast.IndexListExpr{
+			X:       PossiblyAddPackageName(packageName, t.X),
+			Indices: indices,
+		}
+
+	// unions and approximations can only show up in type constraints,
+	// binary expressions also in array lengths
+	case *ast.BinaryExpr:
+		newExpr = &ast.BinaryExpr{
+			X:  PossiblyAddPackageName(packageName, t.X),
+			Op: t.Op,
+			Y:  PossiblyAddPackageName(packageName, t.Y),
+		}
+	case *ast.UnaryExpr:
+		newExpr = &ast.UnaryExpr{
+			Op: t.Op,
+			X:  PossiblyAddPackageName(packageName, t.X),
+		}
 	default:
 		panic(fmt.Sprintf("unsupported type in PossiblyAddPackageName: %T", t))
 	}
@@ -141,15 +215,57 @@ func PossiblyAddPackageName(packageName string, expr ast.Expr) ast.Expr {
 	return newExpr
 }
 
+// copyFieldList qualifies types in a copy of the FieldList, keeping names,
+// nested field lists must not be modified as they can be shared
+func copyFieldList(packageName string, fl *ast.FieldList) *ast.FieldList {
+	if fl == nil {
+		return nil
+	}
+
+	fields := make([]*ast.Field, 0, len(fl.List))
+	for _, field := range fl.List {
+		fields = append(fields, &ast.Field{
+			Names: field.Names,
+			Type:  PossiblyAddPackageName(packageName, field.Type),
+			Tag:   field.Tag,
+		})
+	}
+
+	return &ast.FieldList{List: fields}
+}
+
 func ZeroValue(t ast.Expr) ast.Expr {
 	switch t := t.(type) {
-	case *ast.StarExpr, *ast.ArrayType, *ast.MapType:
+	case *ast.StarExpr, *ast.MapType, *ast.ChanType, *ast.FuncType, *ast.InterfaceType:
 		return ast.NewIdent("nil")
-	case *ast.SelectorExpr:
+	case *ast.ArrayType:
+		if t.Len != nil {
+			return &ast.CompositeLit{
+				Type: t,
+			}
+		}
+
+		return ast.NewIdent("nil")
+	case *ast.StructType:
+		return &ast.CompositeLit{
+			Type: t,
+		}
+	case *ast.ParenExpr:
+		return ZeroValue(t.X)
+	case *ast.SelectorExpr, *ast.IndexExpr, *ast.IndexListExpr:
 		return &ast.CompositeLit{
 			Type: t,
 		}
 	case *ast.Ident:
+		if IsTypeParam(t) {
+			return &ast.StarExpr{
+				X: &ast.CallExpr{
+					Fun:  ast.NewIdent("new"),
+					Args: []ast.Expr{t},
+				},
+			}
+		}
+
 		switch t.Name {
 		case "error":
 			return ast.NewIdent("nil")
@@ -226,3 +342,27 @@ func IsEnumerable(expr ast.Expr) bool {
 		return false
 	}
 }
+
+// IsComparable tells whether values of the type can be compared with == and
+// used as map keys, as far as it can be told from the syntax, named types
+// are assumed comparable
+func IsComparable(expr ast.Expr) bool {
+	switch t := expr.(type) {
+	case *ast.ArrayType:
+		return t.Len != nil && IsComparable(t.Elt)
+	case *ast.MapType, *ast.FuncType, *ast.Ellipsis:
+		return false
+	case *ast.ParenExpr:
+		return IsComparable(t.X)
+	case *ast.StructType:
+		for _, field := range t.Fields.List {
+			if !IsComparable(field.Type) {
+				return false
+			}
+		}
+
+		return true
+	default:
+		return true
+	}
+}
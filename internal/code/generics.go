@@ -0,0 +1,240 @@
+package code
+
+import (
+	"go/ast"
+	"regexp"
+	"strings"
+)
+
+// IsTypeParam checks if the identifier was declared as a type parameter of
+// the parsed interface
+func IsTypeParam(ident *ast.Ident) bool {
+	if ident.Obj == nil || ident.Obj.Kind != ast.Typ {
+		return false
+	}
+
+	_, declaredInFieldList := ident.Obj.Decl.(*ast.Field)
+
+	return declaredInFieldList
+}
+
+// TypeArgs returns type parameters as arguments for instantiating the type,
+// "[K, V]" for "[K comparable, V any]", empty string if there are none
+func TypeArgs(typeParams *ast.FieldList) string {
+	if typeParams == nil {
+		return ""
+	}
+
+	names := []string{}
+	for _, field := range typeParams.List {
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+	}
+
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// typeWord matches names in types, including the ones put as a whole into
+// an identifier like "map[string]abc.Repo", followed by "[" if the type is
+// already instantiated
+var typeWord = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_.]*\[?`)
+
+// AddTypeParams makes declarations generated for generic interface generic
+// as well. Structs that use type parameters, the interface or other such
+// structs, directly or in their methods, get the type parameters, so do
+// functions without receivers using them, every use of those types gets
+// instantiated with the type parameters.
+func AddTypeParams(decls []ast.Decl, typeSpec *ast.TypeSpec, packageName string) []ast.Decl {
+	if typeSpec.TypeParams == nil {
+		return decls
+	}
+
+	typeArgs := TypeArgs(typeSpec.TypeParams)
+	interfaceSelector := packageName + "." + typeSpec.Name.Name
+
+	typeParams := &ast.FieldList{}
+	typeParamNames := map[string]bool{}
+	for _, field := range typeSpec.TypeParams.List {
+		typeParams.List = append(typeParams.List, &ast.Field{
+			Names: field.Names,
+			Type:  PossiblyAddPackageName(packageName, field.Type),
+		})
+
+		for _, name := range field.Names {
+			typeParamNames[name.Name] = true
+		}
+	}
+
+	// identifiers naming fields, methods, or types already instantiated
+	// don't refer to types that need type arguments
+	skipped := map[*ast.Ident]bool{}
+	instantiated := map[*ast.SelectorExpr]bool{}
+	for _, decl := range decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.SelectorExpr:
+				skipped[n.Sel] = true
+			case *ast.KeyValueExpr:
+				if key, ok := n.Key.(*ast.Ident); ok {
+					skipped[key] = true
+				}
+			case *ast.Field:
+				for _, name := range n.Names {
+					skipped[name] = true
+				}
+			case *ast.TypeSpec:
+				skipped[n.Name] = true
+			case *ast.FuncDecl:
+				skipped[n.Name] = true
+			case *ast.IndexExpr:
+				markInstantiated(n.X, skipped, instantiated)
+			case *ast.IndexListExpr:
+				markInstantiated(n.X, skipped, instantiated)
+			}
+
+			return true
+		})
+	}
+
+	isInterface := func(sel *ast.SelectorExpr) bool {
+		pkg, ok := sel.X.(*ast.Ident)
+		return ok && pkg.Name == packageName && sel.Sel.Name == typeSpec.Name.Name
+	}
+
+	generic := map[string]bool{}
+	usesTypeParams := func(node ast.Node) bool {
+		found := false
+		ast.Inspect(node, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.SelectorExpr:
+				found = found || isInterface(n)
+			case *ast.Ident:
+				if skipped[n] {
+					break
+				}
+
+				for _, word := range typeWord.FindAllString(n.Name, -1) {
+					word = strings.TrimSuffix(word, "[")
+					found = found || typeParamNames[word] || generic[word] || word == interfaceSelector
+				}
+			}
+
+			return !found
+		})
+
+		return found
+	}
+
+	structs := map[string]*ast.TypeSpec{}
+	methods := map[string][]*ast.FuncDecl{}
+	for _, decl := range decls {
+		switch d := decl.(type) {
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				if ts, ok := spec.(*ast.TypeSpec); ok {
+					if _, ok := ts.Type.(*ast.StructType); ok {
+						structs[ts.Name.Name] = ts
+					}
+				}
+			}
+		case *ast.FuncDecl:
+			if name := receiverName(d); name != "" {
+				methods[name] = append(methods[name], d)
+			}
+		}
+	}
+
+	// structs become generic also by using other generic structs, repeat
+	// until nothing changes
+	for changed := true; changed; {
+		changed = false
+		for name, ts := range structs {
+			if generic[name] {
+				continue
+			}
+
+			uses := usesTypeParams(ts.Type)
+			for _, method := range methods[name] {
+				uses = uses || usesTypeParams(method)
+			}
+
+			if uses {
+				generic[name] = true
+				changed = true
+			}
+		}
+	}
+
+	genericFuncs := []*ast.FuncDecl{}
+	for _, decl := range decls {
+		if fd, ok := decl.(*ast.FuncDecl); ok && fd.Recv == nil && usesTypeParams(fd) {
+			genericFuncs = append(genericFuncs, fd)
+		}
+	}
+
+	instantiate := func(word string) string {
+		if strings.HasSuffix(word, "[") {
+			return word
+		}
+
+		if generic[word] || word == interfaceSelector {
+			return word + typeArgs
+		}
+
+		return word
+	}
+
+	for _, decl := range decls {
+		ast.Inspect(decl, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.SelectorExpr:
+				if isInterface(n) && !instantiated[n] {
+					n.Sel = ast.NewIdent(n.Sel.Name + typeArgs)
+				}
+			case *ast.Ident:
+				if !skipped[n] {
+					n.Name = typeWord.ReplaceAllStringFunc(n.Name, instantiate)
+				}
+			}
+
+			return true
+		})
+	}
+
+	for name := range generic {
+		structs[name].TypeParams = typeParams
+	}
+
+	for _, fd := range genericFuncs {
+		fd.Type.TypeParams = typeParams
+	}
+
+	return decls
+}
+
+func markInstantiated(x ast.Expr, skipped map[*ast.Ident]bool, instantiated map[*ast.SelectorExpr]bool) {
+	switch t := x.(type) {
+	case *ast.Ident:
+		skipped[t] = true
+	case *ast.SelectorExpr:
+		instantiated[t] = true
+	}
+}
+
+func receiverName(fd *ast.FuncDecl) string {
+	if fd.Recv == nil || len(fd.Recv.List) == 0 {
+		return ""
+	}
+
+	t := fd.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
@@ -76,24 +76,35 @@ func exprString(e ast.Expr) string {
 	case *ast.StarExpr:
 		return "*" + exprString(x.X)
 	case *ast.ArrayType:
+		if x.Len != nil {
+			return "[" + exprString(x.Len) + "]" + exprString(x.Elt)
+		}
 		return "[]" + exprString(x.Elt)
 	case *ast.MapType:
 		return "map[" + exprString(x.Key) + "]" + exprString(x.Value)
 	case *ast.CompositeLit:
 		return fmt.Sprintf("%s{%s}", exprString(x.Type), printExprs(x.Elts))
 	case *ast.CallExpr:
+		if x.Ellipsis.IsValid() {
+			return fmt.Sprintf("%s(%s...)", exprString(x.Fun), printExprs(x.Args))
+		}
 		return fmt.Sprintf("%s(%s)", exprString(x.Fun), printExprs(x.Args))
 	case *ast.BasicLit:
 		return x.Value
 	case *ast.IndexExpr:
 		return fmt.Sprintf("%s[%s]", exprString(x.X), exprString(x.Index))
+	case *ast.IndexListExpr:
+		return fmt.Sprintf("%s[%s]", exprString(x.X), printExprs(x.Indices))
+	case *ast.Ellipsis:
+		return "..." + exprString(x.Elt)
 	case *ast.InterfaceType:
-		return "interface{}"
+		if x.Methods == nil || len(x.Methods.List) == 0 {
+			return "interface{}"
+		}
+		return NodeToString(ast.Expr(x))
 	default:
-		// this might blow up? before it was the return below but not sure
-		// if it was important
-		// return fmt.Sprintf("%T", e)
-		panic(fmt.Sprintf("unsupported type in exprString: %T", e))
+		// channels, functions, structs and the like print fine as they are
+		return NodeToString(e)
 	}
 }
 
@@ -0,0 +1,106 @@
+package code
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"unicode"
+)
+
+// resolved holds what identifiers of the last type checked input refer to,
+// nil for the ones declared neither in the input nor in the universe
+var resolved = map[*ast.Ident]types.Object{}
+
+// CheckTypes type checks the parsed input so qualification can tell
+// builtins, types declared in the input, type parameters and types of the
+// source package that were not pasted apart. The input is rarely complete,
+// errors are expected and ignored. What was resolved for the previous input
+// is dropped, generation works on one input at a time.
+func CheckTypes(fset *token.FileSet, file *ast.File) {
+	resolved = map[*ast.Ident]types.Object{}
+
+	info := &types.Info{
+		Defs: map[*ast.Ident]types.Object{},
+		Uses: map[*ast.Ident]types.Object{},
+	}
+
+	conf := types.Config{
+		Importer: emptyImporter{},
+		Error:    func(error) {},
+	}
+
+	_, _ = conf.Check(file.Name.Name, fset, []*ast.File{file}, info)
+
+	ast.Inspect(file, func(node ast.Node) bool {
+		ident, ok := node.(*ast.Ident)
+		if !ok {
+			return true
+		}
+
+		if obj, ok := info.Uses[ident]; ok {
+			resolved[ident] = obj
+		} else {
+			resolved[ident] = info.Defs[ident]
+		}
+
+		return true
+	})
+}
+
+// TypeOf returns the type the identifier of the input refers to, false
+// when it is declared neither in the input nor in the universe
+func TypeOf(ident *ast.Ident) (types.Type, bool) {
+	typeName, ok := resolved[ident].(*types.TypeName)
+	if !ok {
+		return nil, false
+	}
+
+	return typeName.Type(), true
+}
+
+// needsPackageName tells whether the identifier used as a type, or
+// a constant in array length, refers to the source package
+func needsPackageName(ident *ast.Ident) bool {
+	obj, checked := resolved[ident]
+	if !checked {
+		// identifiers created by implementators, not coming from the input
+		return unicode.IsUpper(rune(ident.Name[0])) && !IsTypeParam(ident)
+	}
+
+	switch {
+	case obj == nil:
+		// not pasted, so it's declared next to the interface, unless it's
+		// a type parameter the checker skipped, as in "Page[T]" with
+		// undeclared "Page"
+		return !IsTypeParam(ident)
+	case obj.Parent() == types.Universe:
+		return false
+	case isTypeParamObject(obj):
+		return false
+	default:
+		return obj.Pkg() != nil && obj.Parent() == obj.Pkg().Scope()
+	}
+}
+
+func isTypeParamObject(obj types.Object) bool {
+	typeName, ok := obj.(*types.TypeName)
+	if !ok {
+		return false
+	}
+
+	_, ok = typeName.Type().(*types.TypeParam)
+
+	return ok
+}
+
+// emptyImporter makes imports in the input resolve to empty packages,
+// selectors are never qualified so their contents don't matter
+type emptyImporter struct{}
+
+func (emptyImporter) Import(importPath string) (*types.Package, error) {
+	pkg := types.NewPackage(importPath, path.Base(importPath))
+	pkg.MarkComplete()
+
+	return pkg, nil
+}
@@ -0,0 +1,200 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// contextLines is the number of unchanged lines shown around changes
+const contextLines = 3
+
+type op byte
+
+const (
+	opEqual  op = ' '
+	opDelete op = '-'
+	opInsert op = '+'
+)
+
+type line struct {
+	op   op
+	text string
+}
+
+// Unified returns differences between the texts in unified format, empty
+// string when they are the same
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	lines := compare(splitLines(oldText), splitLines(newText))
+
+	var output strings.Builder
+	fmt.Fprintf(&output, "--- %s\n+++ %s\n", oldName, newName)
+
+	for start := 0; start < len(lines); {
+		// find the next change and collect it with the changes close enough
+		// to share the context
+		first := start
+		for first < len(lines) && lines[first].op == opEqual {
+			first++
+		}
+
+		if first == len(lines) {
+			break
+		}
+
+		last := first
+		for next := first; next < len(lines); next++ {
+			if lines[next].op == opEqual {
+				continue
+			}
+
+			if next-last > 2*contextLines {
+				break
+			}
+
+			last = next
+		}
+
+		from := max(first-contextLines, start)
+		to := min(last+contextLines+1, len(lines))
+
+		writeHunk(&output, lines, from, to)
+
+		start = to
+	}
+
+	return output.String()
+}
+
+func writeHunk(output *strings.Builder, lines []line, from, to int) {
+	oldStart, newStart := 1, 1
+	for _, l := range lines[:from] {
+		if l.op != opInsert {
+			oldStart++
+		}
+
+		if l.op != opDelete {
+			newStart++
+		}
+	}
+
+	oldCount, newCount := 0, 0
+	for _, l := range lines[from:to] {
+		if l.op != opInsert {
+			oldCount++
+		}
+
+		if l.op != opDelete {
+			newCount++
+		}
+	}
+
+	fmt.Fprintf(output, "@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+
+	for _, l := range lines[from:to] {
+		fmt.Fprintf(output, "%c%s\n", l.op, l.text)
+	}
+}
+
+// maxCells bounds the table of compare, lines between the common prefix
+// and suffix of texts bigger than that are shown replaced as a whole
+const maxCells = 1 << 22
+
+// compare aligns lines of both texts on their longest common subsequence,
+// common prefix and suffix are aligned first, generated files usually
+// change in few places
+func compare(oldLines, newLines []string) []line {
+	prefix := 0
+	for prefix < len(oldLines) && prefix < len(newLines) && oldLines[prefix] == newLines[prefix] {
+		prefix++
+	}
+
+	suffix := 0
+	for suffix < len(oldLines)-prefix && suffix < len(newLines)-prefix &&
+		oldLines[len(oldLines)-1-suffix] == newLines[len(newLines)-1-suffix] {
+		suffix++
+	}
+
+	lines := []line{}
+	for _, text := range oldLines[:prefix] {
+		lines = append(lines, line{opEqual, text})
+	}
+
+	oldMiddle := oldLines[prefix : len(oldLines)-suffix]
+	newMiddle := newLines[prefix : len(newLines)-suffix]
+
+	if len(oldMiddle)*len(newMiddle) > maxCells {
+		for _, text := range oldMiddle {
+			lines = append(lines, line{opDelete, text})
+		}
+
+		for _, text := range newMiddle {
+			lines = append(lines, line{opInsert, text})
+		}
+	} else {
+		lines = append(lines, align(oldMiddle, newMiddle)...)
+	}
+
+	for _, text := range oldLines[len(oldLines)-suffix:] {
+		lines = append(lines, line{opEqual, text})
+	}
+
+	return lines
+}
+
+func align(oldLines, newLines []string) []line {
+	// common[i][j] is the length of the longest common subsequence of
+	// oldLines[i:] and newLines[j:]
+	common := make([][]int32, len(oldLines)+1)
+	for i := range common {
+		common[i] = make([]int32, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	lines := []line{}
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			lines = append(lines, line{opEqual, oldLines[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			lines = append(lines, line{opDelete, oldLines[i]})
+			i++
+		default:
+			lines = append(lines, line{opInsert, newLines[j]})
+			j++
+		}
+	}
+
+	for ; i < len(oldLines); i++ {
+		lines = append(lines, line{opDelete, oldLines[i]})
+	}
+
+	for ; j < len(newLines); j++ {
+		lines = append(lines, line{opInsert, newLines[j]})
+	}
+
+	return lines
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
@@ -0,0 +1,433 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// wellKnownInterfaces holds method sets of standard library interfaces that
+// can be embedded without declaring them in the input, embedding other
+// entries of the table is allowed
+var wellKnownInterfaces = map[string]string{
+	"error":                    `Error() string`,
+	"fmt.Stringer":             `String() string`,
+	"fmt.GoStringer":           `GoString() string`,
+	"io.Reader":                `Read(p []byte) (n int, err error)`,
+	"io.Writer":                `Write(p []byte) (n int, err error)`,
+	"io.Closer":                `Close() error`,
+	"io.Seeker":                `Seek(offset int64, whence int) (int64, error)`,
+	"io.ReaderAt":              `ReadAt(p []byte, off int64) (n int, err error)`,
+	"io.WriterAt":              `WriteAt(p []byte, off int64) (n int, err error)`,
+	"io.ReaderFrom":            `ReadFrom(r io.Reader) (n int64, err error)`,
+	"io.WriterTo":              `WriteTo(w io.Writer) (n int64, err error)`,
+	"io.ByteReader":            `ReadByte() (byte, error)`,
+	"io.ByteWriter":            `WriteByte(c byte) error`,
+	"io.StringWriter":          `WriteString(s string) (n int, err error)`,
+	"io.ReadWriter":            `io.Reader; io.Writer`,
+	"io.ReadCloser":            `io.Reader; io.Closer`,
+	"io.WriteCloser":           `io.Writer; io.Closer`,
+	"io.ReadWriteCloser":       `io.Reader; io.Writer; io.Closer`,
+	"io.ReadSeeker":            `io.Reader; io.Seeker`,
+	"io.ReadSeekCloser":        `io.Reader; io.Seeker; io.Closer`,
+	"io.WriteSeeker":           `io.Writer; io.Seeker`,
+	"io.ReadWriteSeeker":       `io.Reader; io.Writer; io.Seeker`,
+	"sort.Interface":           `Len() int; Less(i, j int) bool; Swap(i, j int)`,
+	"encoding.TextMarshaler":   `MarshalText() (text []byte, err error)`,
+	"encoding.TextUnmarshaler": `UnmarshalText(text []byte) error`,
+	"encoding.BinaryMarshaler": `MarshalBinary() (data []byte, err error)`,
+	"json.Marshaler":           `MarshalJSON() ([]byte, error)`,
+	"json.Unmarshaler":         `UnmarshalJSON([]byte) error`,
+	"http.Handler":             `ServeHTTP(http.ResponseWriter, *http.Request)`,
+	"driver.Valuer":            `Value() (driver.Value, error)`,
+	"sql.Scanner":              `Scan(src any) error`,
+}
+
+type method struct {
+	name      string
+	signature string
+}
+
+type embeddedResolver struct {
+	interfaces map[string]*ast.TypeSpec
+	resolving  map[string]bool
+}
+
+// expandEmbeddedInterfaces rewrites the input so interfaces embedding other
+// interfaces list their methods instead, implementators see only methods.
+// Interfaces embedded by others in the input are left out,
+// input without embedded interfaces is returned untouched.
+func expandEmbeddedInterfaces(input string) (string, error) {
+	fset := token.NewFileSet()
+
+	filledTemplate := strings.Replace(string(interfaceTempl), "{{TEXT}}", input, 1)
+
+	parsed, err := parser.ParseFile(fset, "main.go", filledTemplate, 0)
+	if err != nil {
+		// not a list of declarations, other templates will deal with it
+		return input, nil
+	}
+
+	resolver := &embeddedResolver{
+		interfaces: map[string]*ast.TypeSpec{},
+		resolving:  map[string]bool{},
+	}
+	embedding := map[string]bool{}
+	embedded := map[string]bool{}
+
+	for _, decl := range parsed.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if _, ok := typeSpec.Type.(*ast.InterfaceType); ok {
+				resolver.interfaces[typeSpec.Name.Name] = typeSpec
+			}
+		}
+	}
+
+	for name, typeSpec := range resolver.interfaces {
+		// constraints have no methods to expand, they stay as they are
+		if resolver.isConstraint(name) {
+			continue
+		}
+
+		for _, field := range typeSpec.Type.(*ast.InterfaceType).Methods.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+
+			embedding[name] = true
+			if embeddedName := embeddedName(field.Type); embeddedName != "" {
+				embedded[embeddedName] = true
+			}
+		}
+	}
+
+	if len(embedding) == 0 {
+		return input, nil
+	}
+
+	decls := []string{}
+
+	for _, decl := range parsed.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			decls = append(decls, resolver.print(decl))
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+
+			_, ok := typeSpec.Type.(*ast.InterfaceType)
+			if !ok || resolver.isConstraint(typeSpec.Name.Name) {
+				decls = append(decls, "type "+resolver.print(typeSpec))
+				continue
+			}
+
+			expanded, err := resolver.expand(typeSpec)
+			if err != nil {
+				return "", err
+			}
+
+			if !embedded[typeSpec.Name.Name] {
+				decls = append(decls, expanded)
+			}
+		}
+	}
+
+	return strings.Join(decls, "\n\n"), nil
+}
+
+// isConstraint tells whether the interface declared in the input can only
+// be used as a type constraint, it has a union or ~T element, embeds
+// comparable or another constraint
+func (r *embeddedResolver) isConstraint(name string) bool {
+	typeSpec, ok := r.interfaces[name]
+	if !ok || r.resolving[name] {
+		return false
+	}
+
+	r.resolving[name] = true
+	defer delete(r.resolving, name)
+
+	for _, field := range typeSpec.Type.(*ast.InterfaceType).Methods.List {
+		if len(field.Names) > 0 {
+			continue
+		}
+
+		switch t := field.Type.(type) {
+		case *ast.BinaryExpr, *ast.UnaryExpr:
+			return true
+		case *ast.Ident:
+			if t.Name == "comparable" || r.isConstraint(t.Name) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// embeddedName returns name of interface declared in the input that is
+// embedded, possibly instantiated, empty string for anything else
+func embeddedName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.IndexExpr:
+		return embeddedName(e.X)
+	case *ast.IndexListExpr:
+		return embeddedName(e.X)
+	default:
+		return ""
+	}
+}
+
+func (r *embeddedResolver) expand(typeSpec *ast.TypeSpec) (string, error) {
+	methods, err := r.methods(typeSpec.Name.Name, typeSpec.Type.(*ast.InterfaceType), nil)
+	if err != nil {
+		return "", err
+	}
+
+	typeParams := ""
+	if typeSpec.TypeParams != nil {
+		params := []string{}
+		for _, field := range typeSpec.TypeParams.List {
+			names := []string{}
+			for _, name := range field.Names {
+				names = append(names, name.Name)
+			}
+
+			params = append(params, strings.Join(names, ", ")+" "+r.print(field.Type))
+		}
+
+		typeParams = "[" + strings.Join(params, ", ") + "]"
+	}
+
+	lines := []string{}
+	for _, m := range methods {
+		lines = append(lines, "\t"+m.name+m.signature)
+	}
+
+	return fmt.Sprintf(
+		"type %s%s interface {\n%s\n}",
+		typeSpec.Name.Name,
+		typeParams,
+		strings.Join(lines, "\n"),
+	), nil
+}
+
+// methods returns method set of the interface in order of declaration,
+// substitutions map type parameters of the interface to type arguments
+func (r *embeddedResolver) methods(
+	owner string,
+	interfaceType *ast.InterfaceType,
+	substitutions map[string]string,
+) ([]method, error) {
+	if r.resolving[owner] {
+		return nil, fmt.Errorf("interface %s embeds itself", owner)
+	}
+
+	r.resolving[owner] = true
+	defer delete(r.resolving, owner)
+
+	methods := []method{}
+	seen := map[string]method{}
+	add := func(m method) error {
+		previous, ok := seen[m.name]
+		if !ok {
+			seen[m.name] = m
+			methods = append(methods, m)
+			return nil
+		}
+
+		if r.withoutNames(previous.signature) != r.withoutNames(m.signature) {
+			return fmt.Errorf(
+				"interface %s has method %s declared with different signatures: %s and %s",
+				owner, m.name, previous.signature, m.signature,
+			)
+		}
+
+		return nil
+	}
+
+	for _, field := range interfaceType.Methods.List {
+		if len(field.Names) > 0 {
+			signature := strings.TrimPrefix(r.substitute(field.Type, substitutions), "func")
+			for _, name := range field.Names {
+				if err := add(method{name: name.Name, signature: signature}); err != nil {
+					return nil, err
+				}
+			}
+
+			continue
+		}
+
+		embeddedMethods, err := r.embeddedMethods(owner, field.Type, substitutions)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, m := range embeddedMethods {
+			if err := add(m); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return methods, nil
+}
+
+func (r *embeddedResolver) embeddedMethods(
+	owner string,
+	expr ast.Expr,
+	substitutions map[string]string,
+) ([]method, error) {
+	var typeArgs []ast.Expr
+
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		typeArgs = []ast.Expr{e.Index}
+		expr = e.X
+	case *ast.IndexListExpr:
+		typeArgs = e.Indices
+		expr = e.X
+	}
+
+	name := r.print(expr)
+
+	if ident, ok := expr.(*ast.Ident); ok {
+		if typeSpec, ok := r.interfaces[ident.Name]; ok {
+			params := []string{}
+			if typeSpec.TypeParams != nil {
+				for _, field := range typeSpec.TypeParams.List {
+					for _, param := range field.Names {
+						params = append(params, param.Name)
+					}
+				}
+			}
+
+			if len(params) != len(typeArgs) {
+				return nil, fmt.Errorf(
+					"interface %s embeds %s with %d type arguments, %s has %d type parameters",
+					owner, name, len(typeArgs), name, len(params),
+				)
+			}
+
+			embeddedSubstitutions := map[string]string{}
+			for n, param := range params {
+				embeddedSubstitutions[param] = r.substitute(typeArgs[n], substitutions)
+			}
+
+			return r.methods(name, typeSpec.Type.(*ast.InterfaceType), embeddedSubstitutions)
+		}
+	}
+
+	if body, ok := wellKnownInterfaces[name]; ok && typeArgs == nil {
+		parsed, err := parser.ParseExpr("interface{" + body + "}")
+		if err != nil {
+			panic(fmt.Sprintf("malformed well known interface %s: %s", name, err))
+		}
+
+		return r.methods(name, parsed.(*ast.InterfaceType), nil)
+	}
+
+	return nil, fmt.Errorf(
+		"interface %s embeds %s which is neither declared in the input nor a well known interface (%s), declare it in the input to expand its methods",
+		owner, name, strings.Join(wellKnownInterfaceNames(), ", "),
+	)
+}
+
+// substitute prints the expression with type parameters replaced by type
+// arguments, the expression itself is left untouched
+func (r *embeddedResolver) substitute(expr ast.Expr, substitutions map[string]string) string {
+	printed := r.print(expr)
+	if len(substitutions) == 0 {
+		return printed
+	}
+
+	copied, err := parser.ParseExpr(printed)
+	if err != nil {
+		panic(fmt.Sprintf("reparsing %s: %s", printed, err))
+	}
+
+	skipped := map[*ast.Ident]bool{}
+	ast.Inspect(copied, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.SelectorExpr:
+			skipped[n.Sel] = true
+		case *ast.Field:
+			for _, name := range n.Names {
+				skipped[name] = true
+			}
+		case *ast.Ident:
+			if typeArg, ok := substitutions[n.Name]; ok && !skipped[n] {
+				n.Name = typeArg
+			}
+		}
+
+		return true
+	})
+
+	return r.print(copied)
+}
+
+// withoutNames prints the signature without parameter and result names,
+// signatures differing only in names are identical
+func (r *embeddedResolver) withoutNames(signature string) string {
+	funcType, err := parser.ParseExpr("func" + signature)
+	if err != nil {
+		panic(fmt.Sprintf("reparsing %s: %s", signature, err))
+	}
+
+	ast.Inspect(funcType, func(node ast.Node) bool {
+		fieldList, ok := node.(*ast.FieldList)
+		if !ok {
+			return true
+		}
+
+		// "i, j int" stands for two fields
+		unnamed := []*ast.Field{}
+		for _, field := range fieldList.List {
+			for n := 0; n < max(len(field.Names), 1); n++ {
+				unnamed = append(unnamed, &ast.Field{Type: field.Type})
+			}
+		}
+
+		fieldList.List = unnamed
+
+		return true
+	})
+
+	return r.print(funcType)
+}
+
+// print ignores positions, printed nodes come from different file sets
+func (r *embeddedResolver) print(node any) string {
+	var output strings.Builder
+	if err := printer.Fprint(&output, token.NewFileSet(), node); err != nil {
+		panic(err)
+	}
+
+	return output.String()
+}
+
+func wellKnownInterfaceNames() []string {
+	names := make([]string, 0, len(wellKnownInterfaces))
+	for name := range wellKnownInterfaces {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,297 @@
+package generator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// knownPackages maps names of packages used by generated code to their
+// import paths, imports of the input and the source package take
+// precedence
+var knownPackages = map[string]string{
+	"bytes":        "bytes",
+	"context":      "context",
+	"debug":        "runtime/debug",
+	"errors":       "errors",
+	"fmt":          "fmt",
+	"io":           "io",
+	"json":         "encoding/json",
+	"log":          "log",
+	"os":           "os",
+	"rand":         "math/rand",
+	"slog":         "log/slog",
+	"sort":         "sort",
+	"strconv":      "strconv",
+	"strings":      "strings",
+	"sync":         "sync",
+	"atomic":       "sync/atomic",
+	"time":         "time",
+	"http":         "net/http",
+	"sql":          "database/sql",
+	"driver":       "database/sql/driver",
+	"cache":        "github.com/patrickmn/go-cache",
+	"otel":         "go.opentelemetry.io/otel",
+	"trace":        "go.opentelemetry.io/otel/trace",
+	"codes":        "go.opentelemetry.io/otel/codes",
+	"attribute":    "go.opentelemetry.io/otel/attribute",
+	"singleflight": "golang.org/x/sync/singleflight",
+}
+
+// FileOptions describes the file generated code is emitted into
+type FileOptions struct {
+	// Package is the name of package of the generated file
+	Package string
+	// ImportPath is the import path of package the interface comes from
+	ImportPath string
+	// SamePackage tells the file goes into the package of the interface,
+	// types of that package are not qualified then
+	SamePackage bool
+	// Command that generated the file, put in the header marking the file
+	// as generated, there is no header without it
+	Command []string
+}
+
+// ImplementFile generates a complete, formatted file with the implementation,
+// imports are computed from packages the generated code uses. Packages whose
+// import path is unknown are reported on stderr and left for the user.
+func (g *Generator) ImplementFile(
+	input, implementation, packageName string,
+	options FileOptions,
+) []byte {
+	parsed, generated := g.implement(input, implementation, packageName)
+
+	decls := []string{}
+	for _, group := range generated {
+		for _, decl := range group {
+			var output strings.Builder
+			if err := printer.Fprint(&output, token.NewFileSet(), decl); err != nil {
+				log.Fatal(err)
+			}
+
+			decls = append(decls, output.String())
+		}
+	}
+
+	body := strings.Join(decls, "\n\n")
+	if options.SamePackage {
+		body = unqualify(body, packageName)
+	}
+
+	knownImports := map[string]string{}
+	for name, importPath := range knownPackages {
+		knownImports[name] = importPath
+	}
+
+	if options.ImportPath != "" {
+		knownImports[packageName] = options.ImportPath
+	}
+
+	for _, spec := range parsed.Imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		knownImports[importName(spec.Name, importPath)] = importPath
+	}
+
+	imports := []*ast.ImportSpec{}
+	for _, name := range usedPackages(body) {
+		importPath, ok := knownImports[name]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "import path of package %s is unknown, add it by hand\n", name)
+			continue
+		}
+
+		spec := &ast.ImportSpec{
+			Path: &ast.BasicLit{Kind: token.STRING, Value: strconv.Quote(importPath)},
+		}
+		if importName(nil, importPath) != name {
+			spec.Name = ast.NewIdent(name)
+		}
+
+		imports = append(imports, spec)
+	}
+
+	var file strings.Builder
+	if len(options.Command) > 0 {
+		fmt.Fprintf(&file, "%s\n// %s\n\n", GeneratedHeader, commandLine(options.Command))
+	}
+
+	fmt.Fprintf(&file, "package %s\n\n", options.Package)
+	file.WriteString(importBlock(imports))
+	file.WriteString(body)
+	file.WriteString("\n")
+
+	formatted, err := format.Source([]byte(file.String()))
+	if err != nil {
+		log.Fatalf("Formatting generated file: %s", err)
+	}
+
+	return formatted
+}
+
+// GeneratedHeader marks files written by the tool, following the
+// convention tools rely on to recognize generated code
+const GeneratedHeader = "// Code generated by go-pattern-implement; DO NOT EDIT."
+
+// commandLine joins the arguments so they can be copied into a shell,
+// quoting the ones that would be split or interpreted
+func commandLine(args []string) string {
+	quoted := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`*?[]{}()<>|&;#~") {
+			arg = strconv.Quote(arg)
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+// unqualify removes the package name from types of the package, the code
+// is going into it
+func unqualify(body, packageName string) string {
+	fset := token.NewFileSet()
+
+	parsed := parseBody(fset, body)
+
+	astutil.Apply(parsed, func(cursor *astutil.Cursor) bool {
+		if selector, ok := cursor.Node().(*ast.SelectorExpr); ok && isPackage(selector.X, packageName) {
+			cursor.Replace(selector.Sel)
+		}
+
+		return true
+	}, nil)
+
+	decls := []string{}
+	for _, decl := range parsed.Decls {
+		var output strings.Builder
+		if err := printer.Fprint(&output, fset, decl); err != nil {
+			log.Fatal(err)
+		}
+
+		decls = append(decls, output.String())
+	}
+
+	return strings.Join(decls, "\n\n")
+}
+
+func isPackage(expr ast.Expr, packageName string) bool {
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Obj == nil && ident.Name == packageName
+}
+
+func parseBody(fset *token.FileSet, body string) *ast.File {
+	parsed, err := parser.ParseFile(fset, "", "package x\n\n"+body, 0)
+	if err != nil {
+		log.Fatalf("Parsing generated code: %s", err)
+	}
+
+	return parsed
+}
+
+// usedPackages returns names of packages the code refers to, which are
+// selectors on identifiers not declared in the code
+func usedPackages(body string) []string {
+	parsed := parseBody(token.NewFileSet(), body)
+
+	used := map[string]bool{}
+	ast.Inspect(parsed, func(node ast.Node) bool {
+		selector, ok := node.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+
+		if ident, ok := selector.X.(*ast.Ident); ok && ident.Obj == nil {
+			used[ident.Name] = true
+		}
+
+		return true
+	})
+
+	names := make([]string, 0, len(used))
+	for name := range used {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// importName returns the name the package is referred to by, without an
+// explicit one it's assumed the way goimports does, so major versions as in
+// "math/rand/v2" or "gopkg.in/yaml.v3" and "go-" prefixes are left out
+func importName(name *ast.Ident, importPath string) string {
+	if name != nil {
+		return name.Name
+	}
+
+	base := path.Base(importPath)
+	if isMajorVersion(base) && path.Dir(importPath) != "." {
+		base = path.Base(path.Dir(importPath))
+	}
+
+	base = strings.TrimPrefix(base, "go-")
+	if separator := strings.IndexAny(base, ".-"); separator >= 0 {
+		base = base[:separator]
+	}
+
+	return base
+}
+
+func isMajorVersion(element string) bool {
+	if len(element) < 2 || element[0] != 'v' {
+		return false
+	}
+
+	for _, r := range element[1:] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// importBlock prints imports with the standard library grouped first
+func importBlock(imports []*ast.ImportSpec) string {
+	if len(imports) == 0 {
+		return ""
+	}
+
+	std := []string{}
+	other := []string{}
+	for _, spec := range imports {
+		line := spec.Path.Value
+		if spec.Name != nil {
+			line = spec.Name.Name + " " + line
+		}
+
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+		if strings.Contains(strings.Split(importPath, "/")[0], ".") {
+			other = append(other, "\t"+line)
+		} else {
+			std = append(std, "\t"+line)
+		}
+	}
+
+	groups := []string{}
+	for _, group := range [][]string{std, other} {
+		if len(group) > 0 {
+			groups = append(groups, strings.Join(group, "\n"))
+		}
+	}
+
+	return "import (\n" + strings.Join(groups, "\n\n") + "\n)\n\n"
+}
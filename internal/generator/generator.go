@@ -10,15 +10,29 @@ import (
 	"os"
 	"strings"
 
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/implementations/batch"
 	"github.com/relardev/go-pattern-implement/internal/implementations/cache"
+	"github.com/relardev/go-pattern-implement/internal/implementations/circuitbreaker"
+	"github.com/relardev/go-pattern-implement/internal/implementations/errwrap"
+	"github.com/relardev/go-pattern-implement/internal/implementations/fallback"
 	"github.com/relardev/go-pattern-implement/internal/implementations/filter"
 	filterreturn "github.com/relardev/go-pattern-implement/internal/implementations/filter_return"
 	"github.com/relardev/go-pattern-implement/internal/implementations/filterparam"
+	plainlog "github.com/relardev/go-pattern-implement/internal/implementations/log"
 	"github.com/relardev/go-pattern-implement/internal/implementations/metrics"
+	"github.com/relardev/go-pattern-implement/internal/implementations/mock"
+	"github.com/relardev/go-pattern-implement/internal/implementations/panicrecover"
+	"github.com/relardev/go-pattern-implement/internal/implementations/parallel"
+	"github.com/relardev/go-pattern-implement/internal/implementations/recorder"
+	"github.com/relardev/go-pattern-implement/internal/implementations/renew"
+	"github.com/relardev/go-pattern-implement/internal/implementations/retry"
 	"github.com/relardev/go-pattern-implement/internal/implementations/semaphore"
+	"github.com/relardev/go-pattern-implement/internal/implementations/singleflight"
 	"github.com/relardev/go-pattern-implement/internal/implementations/slog"
 	"github.com/relardev/go-pattern-implement/internal/implementations/store"
 	"github.com/relardev/go-pattern-implement/internal/implementations/throttle"
+	"github.com/relardev/go-pattern-implement/internal/implementations/timeout"
 	"github.com/relardev/go-pattern-implement/internal/implementations/tracing"
 
 	filegetter "github.com/relardev/go-pattern-implement/internal/implementations/file_getter"
@@ -62,6 +76,11 @@ func NewGenerator(printResult bool) *Generator {
 }
 
 func (g *Generator) ListAvailableImplementators(input string) ([]string, error) {
+	input, err := expandEmbeddedInterfaces(input)
+	if err != nil {
+		return nil, err
+	}
+
 	fset := token.NewFileSet()
 
 	implementators := g.implementators("aaa")
@@ -86,7 +105,9 @@ func (g *Generator) ListAvailableImplementators(input string) ([]string, error)
 			log.Fatalf("None of the themplates parsed, last error: %s", err)
 		}
 
-		wrappedVisitor := g.wrap(possible.Visit)
+		code.CheckTypes(fset, parsed)
+
+		wrappedVisitor := g.wrap(possible.Visit, "aaa", func([]ast.Decl) {})
 		recoverable := func() {
 			defer func() {
 				_ = recover()
@@ -106,12 +127,28 @@ func (g *Generator) ListAvailableImplementators(input string) ([]string, error)
 }
 
 func (g *Generator) Implement(input, implementation, packageName string) {
+	_, generated := g.implement(input, implementation, packageName)
+
+	if g.printResult {
+		for _, decls := range generated {
+			printer.Fprint(os.Stdout, token.NewFileSet(), decls)
+			fmt.Fprint(os.Stdout, "\n")
+		}
+	}
+}
+
+// implement returns parsed input and declarations generated for every
+// implemented type in it
+func (g *Generator) implement(input, implementation, packageName string) (*ast.File, [][]ast.Decl) {
+	input, err := expandEmbeddedInterfaces(input)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	fset := token.NewFileSet()
 
 	var parsed *ast.File
 
-	var err error
-
 	for _, template := range templates {
 		filledTemplate := strings.Replace(string(template), "{{TEXT}}", input, 1)
 
@@ -125,6 +162,8 @@ func (g *Generator) Implement(input, implementation, packageName string) {
 		log.Fatalf("None of the templates parsed, last error: %s", err)
 	}
 
+	code.CheckTypes(fset, parsed)
+
 	var visitor func(ast.Node) (bool, []ast.Decl)
 
 	implementators := g.implementators(packageName)
@@ -141,9 +180,14 @@ func (g *Generator) Implement(input, implementation, packageName string) {
 		os.Exit(1)
 	}
 
-	wrappedVisitor := g.wrap(visitor)
+	generated := [][]ast.Decl{}
+	wrappedVisitor := g.wrap(visitor, packageName, func(decls []ast.Decl) {
+		generated = append(generated, decls)
+	})
 
 	ast.Inspect(parsed, wrappedVisitor)
+
+	return parsed, generated
 }
 
 func (g *Generator) ListAllImplementators() []string {
@@ -170,7 +214,10 @@ func (g *Generator) implementators(packageName string) []implementator {
 		store.New(packageName, store.PanicInNew),
 		store.New(packageName, store.ReturnErrorInNew),
 		cache.New(packageName),
-		semaphore.New(packageName),
+		semaphore.New(packageName, semaphore.ModeBasic),
+		semaphore.New(packageName, semaphore.ModeWithCancel),
+		semaphore.New(packageName, semaphore.ModeWithWaitGroup),
+		semaphore.New(packageName, semaphore.ModeWithWaitGroupAndCancel),
 		throttle.New(packageName, throttle.ModeNoError),
 		throttle.New(packageName, throttle.ModeWithError),
 		filter.New(packageName, filter.ModeWithError),
@@ -178,27 +225,67 @@ func (g *Generator) implementators(packageName string) []implementator {
 		filterreturn.New(packageName),
 		filterparam.New(packageName),
 		tracing.New(packageName),
+		retry.New(packageName),
+		renew.New(packageName),
+		batch.New(packageName),
+		parallel.New(packageName),
+		plainlog.New(packageName),
+		circuitbreaker.New(packageName),
+		timeout.New(packageName),
+		singleflight.New(packageName),
+		fallback.New(packageName),
+		mock.New(packageName),
+		recorder.New(packageName),
+		panicrecover.New(packageName),
+		errwrap.New(packageName),
 	}
 }
 
 func (g *Generator) wrap(
 	visitor func(ast.Node) (bool, []ast.Decl),
+	packageName string,
+	collect func([]ast.Decl),
 ) func(ast.Node) bool {
 	return func(node ast.Node) bool {
 		if node == nil {
 			return true
 		}
 
+		// other types declared in the input are only referenced
+		if typeSpec, ok := node.(*ast.TypeSpec); ok && !isImplementable(typeSpec) {
+			return false
+		}
+
 		keepGoing, decls := visitor(node)
 		if !keepGoing {
-			if g.printResult {
-				printer.Fprint(os.Stdout, token.NewFileSet(), decls)
-				fmt.Fprint(os.Stdout, "\n")
+			if typeSpec, ok := node.(*ast.TypeSpec); ok {
+				decls = code.AddTypeParams(decls, typeSpec, packageName)
 			}
 
+			collect(decls)
+
 			return false
 		}
 
 		return true
 	}
 }
+
+func isImplementable(typeSpec *ast.TypeSpec) bool {
+	switch t := typeSpec.Type.(type) {
+	case *ast.InterfaceType:
+		// embedded interfaces are expanded by then, interfaces still
+		// embedding something are type constraints
+		for _, field := range t.Methods.List {
+			if len(field.Names) == 0 {
+				return false
+			}
+		}
+
+		return true
+	case *ast.FuncType:
+		return true
+	default:
+		return false
+	}
+}
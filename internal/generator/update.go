@@ -0,0 +1,625 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/tools/go/ast/astutil"
+
+	"github.com/relardev/go-pattern-implement/internal/naming"
+)
+
+// UpdateResult is the existing file with the generated code it was missing
+type UpdateResult struct {
+	Source []byte
+	// Added lists declarations and fields added to the file
+	Added []string
+	// Regenerated lists constructors replaced by the generated ones, so
+	// fields added to or removed from their structs are initialized
+	Regenerated []string
+	// Stale lists methods of the generated types that are not generated
+	// anymore, removed only when asked to along with fields and types
+	// generated for them
+	Stale []string
+}
+
+type edit struct {
+	offset int
+	end    int
+	text   string
+}
+
+// Update adds declarations of the generated file missing in the existing
+// one, fields missing in its structs and imports they need. Declarations
+// present in both are left alone, so changes made to them by hand stay,
+// except constructors of structs whose fields changed. Methods whose
+// signature differs from the generated one are reported as an error.
+func Update(existing, generated []byte, removeStale bool) (*UpdateResult, error) {
+	fset := token.NewFileSet()
+
+	existingFile, err := parser.ParseFile(fset, "existing.go", existing, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing existing file: %w", err)
+	}
+
+	generatedFset := token.NewFileSet()
+
+	generatedFile, err := parser.ParseFile(generatedFset, "generated.go", generated, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing generated file: %w", err)
+	}
+
+	result := &UpdateResult{}
+	edits := []edit{}
+
+	generatedText := func(from, to token.Pos) string {
+		return string(generated[generatedFset.Position(from).Offset:generatedFset.Position(to).Offset])
+	}
+
+	existingText := func(from, to token.Pos) string {
+		return string(existing[fset.Position(from).Offset:fset.Position(to).Offset])
+	}
+
+	existingDecls := declarations(existingFile)
+	generatedDecls := declarations(generatedFile)
+	existingStructs := structs(existingFile)
+
+	// wrappers are the types generated methods belong to
+	wrappers := map[string]bool{}
+	for _, decl := range generatedFile.Decls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+			wrappers[receiverTypeName(funcDecl)] = true
+		}
+	}
+
+	generatedStructs := structs(generatedFile)
+	// reshaped are structs fields were added to or removed from
+	reshaped := map[string]bool{}
+
+	for _, decl := range generatedFile.Decls {
+		name := declarationKey(decl)
+
+		structType, ok := generatedStructs[name]
+		if !ok {
+			continue
+		}
+
+		existingStruct, ok := existingStructs[name]
+		if !ok {
+			continue
+		}
+
+		fields := map[string]bool{}
+		for _, field := range existingStruct.Fields.List {
+			for _, fieldName := range field.Names {
+				fields[fieldName.Name] = true
+			}
+		}
+
+		missing := ""
+		for _, field := range structType.Fields.List {
+			if len(field.Names) == 0 || fields[field.Names[0].Name] {
+				continue
+			}
+
+			result.Added = append(result.Added, name+"."+field.Names[0].Name)
+			missing += "\t" + generatedText(field.Pos(), field.End()) + "\n"
+			reshaped[name] = true
+		}
+
+		if missing != "" {
+			closing := fset.Position(existingStruct.Fields.Closing).Offset
+			edits = append(edits, edit{offset: closing, end: closing, text: missing})
+		}
+	}
+
+	missing := ""
+	for _, decl := range generatedFile.Decls {
+		key := declarationKey(decl)
+		if key == "" || existingDecls[key] != nil {
+			continue
+		}
+
+		from := decl.Pos()
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Doc != nil {
+			from = funcDecl.Doc.Pos()
+		}
+
+		result.Added = append(result.Added, key)
+		missing += "\n" + generatedText(from, decl.End()) + "\n"
+	}
+
+	if missing != "" {
+		edits = append(edits, edit{offset: len(existing), end: len(existing), text: missing})
+	}
+
+	changed := []string{}
+	staleMethods := []string{}
+
+	for _, decl := range existingFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || !wrappers[receiverTypeName(funcDecl)] {
+			continue
+		}
+
+		key := declarationKey(funcDecl)
+		if generatedFunc, ok := generatedDecls[key].(*ast.FuncDecl); ok {
+			existingSignature := signature(fset, funcDecl.Type)
+			generatedSignature := signature(generatedFset, generatedFunc.Type)
+
+			if existingSignature != generatedSignature {
+				changed = append(changed, fmt.Sprintf("%s is %s, generated as %s",
+					key, existingSignature, generatedSignature))
+			}
+
+			continue
+		}
+
+		result.Stale = append(result.Stale, key)
+		staleMethods = append(staleMethods, funcDecl.Name.Name)
+
+		if removeStale {
+			edits = append(edits, removal(fset, funcDecl.Doc, funcDecl))
+		}
+	}
+
+	if len(changed) > 0 {
+		return nil, fmt.Errorf("signatures changed, remove the methods with fields and types generated "+
+			"for them to have them generated again: %s", strings.Join(changed, "; "))
+	}
+
+	if removeStale && len(staleMethods) > 0 {
+		edits = append(edits, removeStaleRelated(
+			fset, existing, existingFile, generatedDecls, generatedStructs,
+			wrappers, staleMethods, reshaped, result,
+		)...)
+	}
+
+	// constructors written before don't initialize the added fields, or
+	// initialize the removed ones
+	for _, decl := range generatedFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv != nil || !reshaped[constructedTypeName(funcDecl)] {
+			continue
+		}
+
+		existingFunc, ok := existingDecls[funcDecl.Name.Name].(*ast.FuncDecl)
+		if !ok {
+			continue
+		}
+
+		text := generatedText(funcDecl.Pos(), funcDecl.End())
+		if existingText(existingFunc.Pos(), existingFunc.End()) == text {
+			continue
+		}
+
+		result.Regenerated = append(result.Regenerated, funcDecl.Name.Name)
+		edits = append(edits, edit{
+			offset: fset.Position(existingFunc.Pos()).Offset,
+			end:    fset.Position(existingFunc.End()).Offset,
+			text:   text,
+		})
+	}
+
+	if len(edits) == 0 {
+		result.Source = existing
+		return result, nil
+	}
+
+	// applied from the end, so offsets of the remaining edits hold
+	sort.Slice(edits, func(i, j int) bool {
+		return edits[i].offset > edits[j].offset
+	})
+
+	source := existing
+	for _, e := range edits {
+		updated := append([]byte{}, source[:e.offset]...)
+		updated = append(updated, e.text...)
+		source = append(updated, source[e.end:]...)
+	}
+
+	source, err = addImports(source, generatedFile.Imports)
+	if err != nil {
+		return nil, err
+	}
+
+	result.Source = source
+
+	return result, nil
+}
+
+// removeStaleRelated removes fields of the wrappers and types, with their
+// methods, generated for stale methods, like the FindFunc field and the
+// RepoFindCall type of a mock. Their names are told by the ones generated
+// for methods still on the interface, so code added by hand stays even if
+// its name resembles them
+func removeStaleRelated(
+	fset *token.FileSet,
+	existing []byte,
+	existingFile *ast.File,
+	generatedDecls map[string]ast.Decl,
+	generatedStructs map[string]*ast.StructType,
+	wrappers map[string]bool,
+	staleMethods []string,
+	reshaped map[string]bool,
+	result *UpdateResult,
+) []edit {
+	edits := []edit{}
+
+	methods := []string{}
+	generatedNames := []string{}
+	for key, decl := range generatedDecls {
+		if funcDecl, ok := decl.(*ast.FuncDecl); ok && funcDecl.Recv != nil {
+			if wrappers[receiverTypeName(funcDecl)] {
+				methods = append(methods, funcDecl.Name.Name)
+			}
+
+			continue
+		}
+
+		if genDecl, ok := decl.(*ast.GenDecl); ok && genDecl.Tok == token.TYPE {
+			generatedNames = append(generatedNames, key)
+		}
+	}
+
+	for name, structType := range generatedStructs {
+		if !wrappers[name] {
+			continue
+		}
+
+		for _, field := range structType.Fields.List {
+			for _, fieldName := range field.Names {
+				generatedNames = append(generatedNames, fieldName.Name)
+			}
+		}
+	}
+
+	staleNames := map[string]bool{}
+	for _, pattern := range namePatterns(generatedNames, methods) {
+		for _, method := range staleMethods {
+			staleNames[pattern.apply(method)] = true
+		}
+	}
+
+	removedTypes := map[string]bool{}
+
+	for _, decl := range existingFile.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE || len(genDecl.Specs) != 1 {
+			continue
+		}
+
+		name := declarationKey(genDecl)
+
+		if generatedDecls[name] == nil && staleNames[name] {
+			removedTypes[name] = true
+			result.Stale = append(result.Stale, name)
+			edits = append(edits, removal(fset, genDecl.Doc, genDecl))
+
+			continue
+		}
+
+		structType, ok := genDecl.Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+		if !ok || !wrappers[name] || generatedStructs[name] == nil {
+			continue
+		}
+
+		generatedFields := map[string]bool{}
+		for _, field := range generatedStructs[name].Fields.List {
+			for _, fieldName := range field.Names {
+				generatedFields[fieldName.Name] = true
+			}
+		}
+
+		for _, field := range structType.Fields.List {
+			if len(field.Names) != 1 || generatedFields[field.Names[0].Name] || !staleNames[field.Names[0].Name] {
+				continue
+			}
+
+			result.Stale = append(result.Stale, name+"."+field.Names[0].Name)
+			edits = append(edits, fieldRemoval(fset, existing, field))
+			reshaped[name] = true
+		}
+	}
+
+	for _, decl := range existingFile.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || !removedTypes[receiverTypeName(funcDecl)] {
+			continue
+		}
+
+		result.Stale = append(result.Stale, declarationKey(funcDecl))
+		edits = append(edits, removal(fset, funcDecl.Doc, funcDecl))
+	}
+
+	return edits
+}
+
+// namePattern is a name generated for a method, with the method name,
+// capitalized or not, between the prefix and the suffix
+type namePattern struct {
+	prefix string
+	suffix string
+	lower  bool
+}
+
+func (p namePattern) apply(method string) string {
+	if p.lower {
+		method = naming.LowercaseFirstLetter(method)
+	}
+
+	return p.prefix + method + p.suffix
+}
+
+// namePatterns tells patterns of the names generated for methods, each name
+// is taken as generated for the shortest method name it has as a word, like
+// "getCalls" for "Get" and not for "GetCalls"
+func namePatterns(names, methods []string) []namePattern {
+	sort.Slice(methods, func(i, j int) bool {
+		return len(methods[i]) < len(methods[j])
+	})
+
+	patterns := []namePattern{}
+	seen := map[namePattern]bool{}
+
+	for _, name := range names {
+		for _, method := range methods {
+			pattern, ok := patternOf(name, method)
+			if !ok {
+				continue
+			}
+
+			// the name of the method itself, not generated for it
+			if pattern.prefix != "" || pattern.suffix != "" {
+				if !seen[pattern] {
+					seen[pattern] = true
+					patterns = append(patterns, pattern)
+				}
+			}
+
+			break
+		}
+	}
+
+	return patterns
+}
+
+// patternOf finds the method name as a word of the name, in lower case at
+// its start or capitalized anywhere in it
+func patternOf(name, method string) (namePattern, bool) {
+	lower := naming.LowercaseFirstLetter(method)
+	if strings.HasPrefix(name, lower) && wordEnds(name, len(lower)) {
+		return namePattern{suffix: name[len(lower):], lower: true}, true
+	}
+
+	for from := 0; from < len(name); {
+		found := strings.Index(name[from:], method)
+		if found < 0 {
+			break
+		}
+
+		end := from + found + len(method)
+		if wordEnds(name, end) {
+			return namePattern{prefix: name[:from+found], suffix: name[end:]}, true
+		}
+
+		from += found + 1
+	}
+
+	return namePattern{}, false
+}
+
+func wordEnds(name string, at int) bool {
+	return at == len(name) || !unicode.IsLower(rune(name[at]))
+}
+
+// removal removes the declaration along with its doc comment
+func removal(fset *token.FileSet, doc *ast.CommentGroup, decl ast.Decl) edit {
+	from := decl.Pos()
+	if doc != nil {
+		from = doc.Pos()
+	}
+
+	return edit{
+		offset: fset.Position(from).Offset,
+		end:    fset.Position(decl.End()).Offset,
+	}
+}
+
+// fieldRemoval removes whole lines of the field, with its comments
+func fieldRemoval(fset *token.FileSet, existing []byte, field *ast.Field) edit {
+	from, to := field.Pos(), field.End()
+	if field.Doc != nil {
+		from = field.Doc.Pos()
+	}
+
+	if field.Comment != nil {
+		to = field.Comment.End()
+	}
+
+	offset := fset.Position(from).Offset
+	for offset > 0 && existing[offset-1] != '\n' {
+		offset--
+	}
+
+	end := fset.Position(to).Offset
+	for end < len(existing) && existing[end] != '\n' {
+		end++
+	}
+
+	if end < len(existing) {
+		end++
+	}
+
+	return edit{offset: offset, end: end}
+}
+
+// signature returns types of the params and results, without names of
+// params, those can be changed by hand
+func signature(fset *token.FileSet, funcType *ast.FuncType) string {
+	types := func(fields *ast.FieldList) string {
+		if fields == nil {
+			return ""
+		}
+
+		list := []string{}
+		for _, field := range fields.List {
+			var t bytes.Buffer
+			if err := printer.Fprint(&t, fset, field.Type); err != nil {
+				panic(err)
+			}
+
+			for range max(len(field.Names), 1) {
+				list = append(list, t.String())
+			}
+		}
+
+		return strings.Join(list, ", ")
+	}
+
+	return "func(" + types(funcType.Params) + ") (" + types(funcType.Results) + ")"
+}
+
+// constructedTypeName returns name of the type the function returns first,
+// without pointer and type parameters
+func constructedTypeName(funcDecl *ast.FuncDecl) string {
+	results := funcDecl.Type.Results
+	if results == nil || len(results.List) == 0 {
+		return ""
+	}
+
+	t := results.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+
+	switch r := t.(type) {
+	case *ast.IndexExpr:
+		t = r.X
+	case *ast.IndexListExpr:
+		t = r.X
+	}
+
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
+
+// addImports adds the imports the updated code uses and the file lacks
+func addImports(source []byte, imports []*ast.ImportSpec) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "updated.go", source, parser.ParseComments)
+	if err != nil {
+		return nil, fmt.Errorf("parsing updated file: %w", err)
+	}
+
+	for _, spec := range imports {
+		importPath, _ := strconv.Unquote(spec.Path.Value)
+
+		name := ""
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+
+		if astutil.AddNamedImport(fset, file, name, importPath) && !astutil.UsesImport(file, importPath) {
+			astutil.DeleteNamedImport(fset, file, name, importPath)
+		}
+	}
+
+	var output bytes.Buffer
+	if err := format.Node(&output, fset, file); err != nil {
+		return nil, fmt.Errorf("formatting updated file: %w", err)
+	}
+
+	return output.Bytes(), nil
+}
+
+// declarations maps keys of declarations to them, methods are keyed by
+// receiver type and name, everything else by name
+func declarations(file *ast.File) map[string]ast.Decl {
+	decls := map[string]ast.Decl{}
+	for _, decl := range file.Decls {
+		if key := declarationKey(decl); key != "" {
+			decls[key] = decl
+		}
+	}
+
+	return decls
+}
+
+func declarationKey(decl ast.Decl) string {
+	switch d := decl.(type) {
+	case *ast.FuncDecl:
+		if d.Recv != nil {
+			return receiverTypeName(d) + "." + d.Name.Name
+		}
+
+		return d.Name.Name
+	case *ast.GenDecl:
+		if len(d.Specs) == 0 {
+			return ""
+		}
+
+		switch spec := d.Specs[0].(type) {
+		case *ast.TypeSpec:
+			return spec.Name.Name
+		case *ast.ValueSpec:
+			return spec.Names[0].Name
+		}
+	}
+
+	return ""
+}
+
+func structs(file *ast.File) map[string]*ast.StructType {
+	found := map[string]*ast.StructType{}
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				found[typeSpec.Name.Name] = structType
+			}
+		}
+	}
+
+	return found
+}
+
+// receiverTypeName returns name of the receiver type, without pointer and
+// type parameters
+func receiverTypeName(funcDecl *ast.FuncDecl) string {
+	t := funcDecl.Recv.List[0].Type
+	if star, ok := t.(*ast.StarExpr); ok {
+		t = star.X
+	}
+
+	switch r := t.(type) {
+	case *ast.IndexExpr:
+		t = r.X
+	case *ast.IndexListExpr:
+		t = r.X
+	}
+
+	if ident, ok := t.(*ast.Ident); ok {
+		return ident.Name
+	}
+
+	return ""
+}
@@ -0,0 +1,581 @@
+package batch
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type resultKind int
+
+const (
+	resultNone resultKind = iota
+	resultError
+	resultListAndError
+)
+
+type param struct {
+	name *ast.Ident
+	typ  ast.Expr
+}
+
+// pair is a single item method together with the method that accepts
+// a slice of those items
+type pair struct {
+	single     *ast.Field
+	batch      *ast.Field
+	itemPos    int
+	results    resultKind
+	resultType ast.Expr
+}
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "batch"
+}
+
+func (i *Implementator) Description() string {
+	return "Coalesce single item calls into calls of the batch method, flushing on size or time threshold"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			methods := interfaceNode.Methods.List
+			for _, methodDef := range methods {
+				funcType := methodDef.Type.(*ast.FuncType)
+				code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+				code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+				naming.ExtractFuncArgs(methodDef)
+			}
+
+			pairs := findPairs(methods)
+			if len(pairs) == 0 {
+				panic("expected a method taking a single item and a method taking a slice of those items")
+			}
+
+			fields := []code.StructField{
+				code.FieldFromTypeSpec(typeSpec, i.packageName),
+				{
+					Name:    "maxSize",
+					TypeStr: "int",
+				},
+				{
+					Name:    "maxWait",
+					TypeStr: "time.Duration",
+				},
+				{
+					Name:    "mu",
+					TypeStr: "sync.Mutex",
+				},
+			}
+			for _, p := range pairs {
+				fields = append(fields, code.StructField{
+					Name:    pendingName(p),
+					TypeStr: fmt.Sprintf("map[%s]*%s", keyName(p), batchName(p)),
+				})
+			}
+
+			decls = append(decls, code.Struct("Batcher", fields...))
+			decls = append(decls, i.newWraperFunction(pairs))
+
+			for _, methodDef := range methods {
+				p := pairFor(pairs, methodDef)
+				if p == nil {
+					decls = append(decls, i.forwardFunction(methodDef))
+					continue
+				}
+
+				decls = append(decls, i.keyStruct(p))
+				decls = append(decls, i.callStruct(p))
+				decls = append(decls, code.Struct(
+					batchName(p),
+					code.StructField{
+						Name:    "calls",
+						TypeStr: "[]*" + callName(p),
+					},
+					code.StructField{
+						Name:    "timer",
+						TypeStr: "*time.Timer",
+					},
+				))
+				decls = append(decls, i.implementFunction(p))
+				decls = append(decls, i.flushFunction(p))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction(pairs []*pair) ast.Decl {
+	pending := []string{}
+	for _, p := range pairs {
+		pending = append(pending, fmt.Sprintf(
+			"%s: map[%s]*%s{},",
+			pendingName(p), keyName(p), batchName(p),
+		))
+	}
+
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+		"pending":           strings.Join(pending, "\n"),
+	}, `
+	func New({{firstLetter}} {{interfaceSelector}}, maxSize int, maxWait time.Duration) *Batcher {
+		return &Batcher{
+			{{firstLetter}}: {{firstLetter}},
+			maxSize: maxSize,
+			maxWait: maxWait,
+			{{pending}}
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) keyStruct(p *pair) ast.Decl {
+	fields := []code.StructField{}
+	for n, prm := range flatten(p.single.Type.(*ast.FuncType).Params) {
+		if n == p.itemPos || code.IsContext(prm.typ) {
+			continue
+		}
+
+		fields = append(fields, code.StructField{
+			Name:     prm.name.Name,
+			TypeSpec: prm.typ,
+		})
+	}
+
+	return code.Struct(keyName(p), fields...)
+}
+
+func (i *Implementator) callStruct(p *pair) ast.Decl {
+	item := flatten(p.single.Type.(*ast.FuncType).Params)[p.itemPos]
+
+	fields := []code.StructField{
+		{
+			Name:     item.name.Name,
+			TypeSpec: item.typ,
+		},
+	}
+
+	if p.results == resultListAndError {
+		fields = append(fields, code.StructField{
+			Name:     "result",
+			TypeSpec: p.resultType,
+		})
+	}
+
+	if p.results != resultNone {
+		fields = append(fields, code.StructField{
+			Name:    "err",
+			TypeStr: "error",
+		})
+	}
+
+	fields = append(fields, code.StructField{
+		Name:    "done",
+		TypeStr: "chan struct{}",
+	})
+
+	return code.Struct(callName(p), fields...)
+}
+
+func (i *Implementator) implementFunction(p *pair) ast.Decl {
+	funcType := p.single.Type.(*ast.FuncType)
+	params := flatten(funcType.Params)
+
+	keyElts := []string{}
+	var ctx *ast.Ident
+	for n, prm := range params {
+		if n == p.itemPos {
+			continue
+		}
+
+		if code.IsContext(prm.typ) {
+			ctx = prm.name
+			continue
+		}
+
+		keyElts = append(keyElts, fmt.Sprintf("%s: %s", prm.name.Name, prm.name.Name))
+	}
+
+	var returnCall, returnCanceled string
+	switch p.results {
+	case resultNone:
+		returnCall = "return"
+		returnCanceled = "return"
+	case resultError:
+		returnCall = "return call.err"
+		if ctx != nil {
+			returnCanceled = fmt.Sprintf("return %s.Err()", ctx.Name)
+		}
+	case resultListAndError:
+		returnCall = "return call.result, call.err"
+		if ctx != nil {
+			returnCanceled = fmt.Sprintf(
+				"return %s, %s.Err()",
+				code.NodeToString(code.ZeroValue(p.resultType)),
+				ctx.Name,
+			)
+		}
+	}
+
+	var wait string
+	if ctx != nil {
+		wait = fstr.Sprintf(map[string]any{
+			"ctx":            ctx,
+			"returnCall":     returnCall,
+			"returnCanceled": returnCanceled,
+		}, `
+	select {
+	case <-call.done:
+		{{returnCall}}
+	case <-{{ctx}}.Done():
+		{{returnCanceled}}
+	}`)
+	} else {
+		wait = fstr.Sprintf(map[string]any{
+			"returnCall": returnCall,
+		}, `
+	<-call.done
+	{{returnCall}}`)
+	}
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"fnName":   p.single.Names[0].Name,
+		"args":     funcType.Params,
+		"results":  funcType.Results,
+		"itemName": params[p.itemPos].name,
+		"callName": callName(p),
+		"key":      fmt.Sprintf("%s{%s}", keyName(p), strings.Join(keyElts, ", ")),
+		"pending":  pendingName(p),
+		"batch":    batchName(p),
+		"flush":    flushName(p),
+		"wait":     wait,
+	}, `
+func (b *Batcher) {{fnName}}({{args}}) ({{results}}) {
+	call := &{{callName}}{ {{itemName}}: {{itemName}}, done: make(chan struct{}) }
+	key := {{key}}
+
+	b.mu.Lock()
+	batch, ok := b.{{pending}}[key]
+	if !ok {
+		batch = &{{batch}}{}
+		batch.timer = time.AfterFunc(b.maxWait, func() { b.{{flush}}(key, batch) })
+		b.{{pending}}[key] = batch
+	}
+	batch.calls = append(batch.calls, call)
+	full := len(batch.calls) >= b.maxSize
+	b.mu.Unlock()
+
+	if full {
+		b.{{flush}}(key, batch)
+	}
+	{{wait}}
+}`))
+}
+
+func (i *Implementator) flushFunction(p *pair) ast.Decl {
+	singleParams := flatten(p.single.Type.(*ast.FuncType).Params)
+	batchParams := flatten(p.batch.Type.(*ast.FuncType).Params)
+
+	callArgs := []string{}
+	for n, prm := range singleParams {
+		switch {
+		case n == p.itemPos:
+			callArgs = append(callArgs, "items")
+		case code.IsContext(prm.typ):
+			callArgs = append(callArgs, "context.Background()")
+		default:
+			callArgs = append(callArgs, "key."+prm.name.Name)
+		}
+	}
+
+	call := fmt.Sprintf(
+		"b.%s.%s(%s)",
+		string(unicode.ToLower(rune(i.interfaceName[0]))),
+		p.batch.Names[0].Name,
+		strings.Join(callArgs, ", "),
+	)
+
+	var distribute string
+	switch p.results {
+	case resultNone:
+		distribute = fstr.Sprintf(map[string]any{
+			"call": call,
+		}, `
+	{{call}}
+	for _, call := range batch.calls {
+		close(call.done)
+	}`)
+	case resultError:
+		distribute = fstr.Sprintf(map[string]any{
+			"call": call,
+		}, `
+	err := {{call}}
+	for _, call := range batch.calls {
+		call.err = err
+		close(call.done)
+	}`)
+	case resultListAndError:
+		distribute = fstr.Sprintf(map[string]any{
+			"call": call,
+		}, `
+	results, err := {{call}}
+	if err == nil && len(results) != len(batch.calls) {
+		err = fmt.Errorf("batch returned %d results for %d items", len(results), len(batch.calls))
+	}
+	for n, call := range batch.calls {
+		if err == nil {
+			call.result = results[n]
+		}
+		call.err = err
+		close(call.done)
+	}`)
+	}
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"flush":      flushName(p),
+		"key":        keyName(p),
+		"batch":      batchName(p),
+		"pending":    pendingName(p),
+		"itemsType":  batchParams[p.itemPos].typ,
+		"itemName":   singleParams[p.itemPos].name,
+		"distribute": distribute,
+	}, `
+func (b *Batcher) {{flush}}(key {{key}}, batch *{{batch}}) {
+	b.mu.Lock()
+	if b.{{pending}}[key] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.{{pending}}, key)
+	b.mu.Unlock()
+
+	batch.timer.Stop()
+
+	items := make({{itemsType}}, 0, len(batch.calls))
+	for _, call := range batch.calls {
+		items = append(items, call.{{itemName}})
+	}
+	{{distribute}}
+}`))
+}
+
+func (i *Implementator) forwardFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        funcType.Params,
+		"results":     funcType.Results,
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
+	}
+
+	if funcType.Results == nil {
+		return text.ToDecl(fstr.Sprintf(args, `
+func (b *Batcher) {{fnName}}({{args}}) ({{results}}) {
+	b.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+	}
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func (b *Batcher) {{fnName}}({{args}}) ({{results}}) {
+	return b.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+}
+
+func findPairs(methods []*ast.Field) []*pair {
+	pairs := []*pair{}
+
+	for _, batch := range methods {
+		batchParams := flatten(batch.Type.(*ast.FuncType).Params)
+
+		for pos, prm := range batchParams {
+			if !code.IsEnumerable(prm.typ) {
+				continue
+			}
+
+			list, ok := prm.typ.(*ast.ArrayType)
+			if !ok {
+				continue
+			}
+
+			for _, single := range methods {
+				// a method is batched by the first matching one only
+				if single == batch || pairFor(pairs, single) != nil ||
+					!matchesItem(single, batchParams, pos, list.Elt) {
+					continue
+				}
+
+				// calls are grouped by the other params, which can't be done
+				// when they can't be a map key, such methods are forwarded
+				if !comparableExtraParams(batchParams, pos) {
+					continue
+				}
+
+				kind, resultType, ok := resultsMatch(
+					single.Type.(*ast.FuncType).Results,
+					batch.Type.(*ast.FuncType).Results,
+				)
+				if !ok {
+					continue
+				}
+
+				pairs = append(pairs, &pair{
+					single:     single,
+					batch:      batch,
+					itemPos:    pos,
+					results:    kind,
+					resultType: resultType,
+				})
+			}
+		}
+	}
+
+	return pairs
+}
+
+func comparableExtraParams(params []param, itemPos int) bool {
+	for n, prm := range params {
+		if n != itemPos && !code.IsComparable(prm.typ) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func matchesItem(single *ast.Field, batchParams []param, itemPos int, itemType ast.Expr) bool {
+	singleParams := flatten(single.Type.(*ast.FuncType).Params)
+	if len(singleParams) != len(batchParams) {
+		return false
+	}
+
+	for n, prm := range singleParams {
+		expected := batchParams[n].typ
+		if n == itemPos {
+			expected = itemType
+		}
+
+		if code.NodeToString(prm.typ) != code.NodeToString(expected) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resultsMatch(single, batch *ast.FieldList) (resultKind, ast.Expr, bool) {
+	singleLen, batchLen := fieldListLen(single), fieldListLen(batch)
+
+	switch {
+	case singleLen == 0 && batchLen == 0:
+		return resultNone, nil, true
+	case singleLen == 1 && batchLen == 1:
+		if code.IsError(single.List[0].Type) && code.IsError(batch.List[0].Type) {
+			return resultError, nil, true
+		}
+	case singleLen == 2 && batchLen == 2:
+		list, ok := batch.List[0].Type.(*ast.ArrayType)
+		if !ok || !code.IsError(single.List[1].Type) || !code.IsError(batch.List[1].Type) {
+			return resultNone, nil, false
+		}
+
+		if code.NodeToString(list.Elt) == code.NodeToString(single.List[0].Type) {
+			return resultListAndError, single.List[0].Type, true
+		}
+	}
+
+	return resultNone, nil, false
+}
+
+func pairFor(pairs []*pair, methodDef *ast.Field) *pair {
+	for _, p := range pairs {
+		if p.single == methodDef {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func flatten(fl *ast.FieldList) []param {
+	params := []param{}
+	if fl == nil {
+		return params
+	}
+
+	for _, f := range fl.List {
+		for _, name := range f.Names {
+			params = append(params, param{name: name, typ: f.Type})
+		}
+	}
+
+	return params
+}
+
+func fieldListLen(fl *ast.FieldList) int {
+	if fl == nil {
+		return 0
+	}
+
+	return len(fl.List)
+}
+
+func prefix(p *pair) string {
+	return naming.LowercaseFirstLetter(p.single.Names[0].Name)
+}
+
+func keyName(p *pair) string {
+	return prefix(p) + "Key"
+}
+
+func callName(p *pair) string {
+	return prefix(p) + "Call"
+}
+
+func batchName(p *pair) string {
+	return prefix(p) + "Batch"
+}
+
+func pendingName(p *pair) string {
+	return prefix(p) + "Pending"
+}
+
+func flushName(p *pair) string {
+	return "flush" + p.single.Names[0].Name
+}
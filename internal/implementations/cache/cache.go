@@ -98,11 +98,11 @@ func (i *Implementator) implementFunction(interfaceName string, field *ast.Field
 	t := fstr.Sprintf(map[string]any{
 		"firstLetter": unicode.ToLower(rune(interfaceName[0])),
 		"fnName":      field.Names[0].Name,
-		"args":        field.Type.(*ast.FuncType).Params,
+		"args":        code.AddPackageNameToFieldList(field.Type.(*ast.FuncType).Params, i.packageName),
 		"varType":     result,
 		"varName":     naming.VariableNameFromExpr(result.Type),
 		"zeroValue":   code.ZeroValue(result.Type),
-		"varArgs":     naming.ExtractFuncArgs(field),
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
 		"key":         generateKey(field.Type.(*ast.FuncType).Params),
 	}, `
 func ({{firstLetter}} *Cache) {{fnName}}({{args}}) ({{varType}}, error) {
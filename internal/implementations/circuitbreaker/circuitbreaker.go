@@ -0,0 +1,264 @@
+package circuitbreaker
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "circuit-breaker"
+}
+
+func (i *Implementator) Description() string {
+	return "Stop calling methods that keep failing, try again after cooldown"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			guarded := []*ast.Field{}
+			for _, methodDef := range interfaceNode.Methods.List {
+				returnsError, _ := code.DoesFieldReturnError(methodDef)
+				if returnsError {
+					guarded = append(guarded, methodDef)
+				}
+			}
+
+			if len(guarded) == 0 {
+				panic("expected at least one method returning an error")
+			}
+
+			fields := []code.StructField{
+				code.FieldFromTypeSpec(typeSpec, i.packageName),
+			}
+			for _, methodDef := range guarded {
+				fields = append(fields, code.StructField{
+					Name:    breakerName(methodDef),
+					TypeStr: "*Breaker",
+				})
+			}
+
+			decls = append(decls, text.ToDecl(
+				`var ErrCircuitOpen = errors.New("circuit breaker is open")`,
+			))
+			decls = append(decls, breakerDecls()...)
+			decls = append(decls, code.Struct("CircuitBreaker", fields...))
+			decls = append(decls, i.newWraperFunction(guarded))
+
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func breakerDecls() []ast.Decl {
+	return []ast.Decl{
+		text.ToDecl(`type breakerState int`),
+		text.ToDecl(`
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)`),
+		code.Struct(
+			"Breaker",
+			code.StructField{
+				Name:    "mu",
+				TypeStr: "sync.Mutex",
+			},
+			code.StructField{
+				Name:    "state",
+				TypeStr: "breakerState",
+			},
+			code.StructField{
+				Name:    "failures",
+				TypeStr: "int",
+			},
+			code.StructField{
+				Name:    "openedAt",
+				TypeStr: "time.Time",
+			},
+			code.StructField{
+				Name:    "failureThreshold",
+				TypeStr: "int",
+			},
+			code.StructField{
+				Name:    "cooldown",
+				TypeStr: "time.Duration",
+			},
+		),
+		text.ToDecl(`
+func NewBreaker(failureThreshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		failureThreshold: failureThreshold,
+		cooldown: cooldown,
+	}
+}`),
+		text.ToDecl(`
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case stateOpen:
+		if time.Since(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		b.state = stateHalfOpen
+
+		return true
+	case stateHalfOpen:
+		return false
+	default:
+		return true
+	}
+}`),
+		text.ToDecl(`
+func (b *Breaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.state = stateClosed
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+	if b.state == stateHalfOpen || b.failures >= b.failureThreshold {
+		b.state = stateOpen
+		b.openedAt = time.Now()
+	}
+}`),
+	}
+}
+
+func (i *Implementator) newWraperFunction(guarded []*ast.Field) ast.Decl {
+	breakers := []string{}
+	for _, methodDef := range guarded {
+		breakers = append(breakers, breakerName(methodDef)+": breaker(),")
+	}
+
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+		"breakers":          strings.Join(breakers, "\n"),
+	}, `
+	func New(
+		{{firstLetter}} {{interfaceSelector}},
+		failureThreshold int,
+		cooldown time.Duration,
+		perMethod bool,
+	) *CircuitBreaker {
+		shared := NewBreaker(failureThreshold, cooldown)
+		breaker := func() *Breaker {
+			if perMethod {
+				return NewBreaker(failureThreshold, cooldown)
+			}
+
+			return shared
+		}
+
+		return &CircuitBreaker{
+			{{firstLetter}}: {{firstLetter}},
+			{{breakers}}
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        params,
+		"results":     results,
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
+	}
+
+	returnsError, errorPos := code.DoesFieldListReturnError(results)
+	if !returnsError {
+		if results == nil {
+			return text.ToDecl(fstr.Sprintf(args, `
+func (c *CircuitBreaker) {{fnName}}({{args}}) ({{results}}) {
+	c.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+		}
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (c *CircuitBreaker) {{fnName}}({{args}}) ({{results}}) {
+	return c.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+	}
+
+	zeroReturns := []ast.Expr{}
+	for _, r := range results.List {
+		zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+	}
+	zeroReturns[errorPos] = ast.NewIdent("ErrCircuitOpen")
+
+	resultVars, _ := naming.ExtractResultVars(funcType)
+
+	args["breaker"] = breakerName(field)
+	args["zeroReturns"] = zeroReturns
+	args["resultVars"] = resultVars
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func (c *CircuitBreaker) {{fnName}}({{args}}) ({{results}}) {
+	if !c.{{breaker}}.allow() {
+		return {{zeroReturns}}
+	}
+
+	{{resultVars}} := c.{{firstLetter}}.{{fnName}}({{varArgs}})
+	c.{{breaker}}.record(err)
+
+	return {{resultVars}}
+}`))
+}
+
+func breakerName(field *ast.Field) string {
+	return naming.LowercaseFirstLetter(field.Names[0].Name) + "Breaker"
+}
@@ -0,0 +1,188 @@
+package errwrap
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "errwrap"
+}
+
+func (i *Implementator) Description() string {
+	return "Wrap returned errors with interface, method and arguments of the call"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, text.ToDecl(`const DefaultFormat = "%s(%s): %w"`))
+		decls = append(decls, code.Struct(
+			"ErrWrap",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "format",
+				TypeStr: "string",
+			},
+			code.StructField{
+				Name:    "sensitive",
+				TypeStr: "map[string]bool",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+		decls = append(decls, i.wrapFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New(
+		{{firstLetter}} {{interfaceSelector}},
+		format string,
+		sensitive map[string]bool,
+	) *ErrWrap {
+		if format == "" {
+			format = DefaultFormat
+		}
+
+		return &ErrWrap{
+			{{firstLetter}}: {{firstLetter}},
+			format: format,
+			sensitive: sensitive,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+// wrapFunction generates formatting of the error, format gets the
+// Interface.Method, comma separated arguments and the error, arguments
+// flagged as sensitive under "Method.param" key are redacted
+func (i *Implementator) wrapFunction() ast.Decl {
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"interfaceName": i.interfaceName,
+	}, `
+func (e *ErrWrap) wrap(method string, names []string, values []any, err error) error {
+	args := make([]string, len(values))
+	for n, value := range values {
+		if e.sensitive[method+"."+names[n]] {
+			args[n] = "<redacted>"
+			continue
+		}
+
+		args[n] = fmt.Sprint(value)
+	}
+
+	return fmt.Errorf(e.format, "{{interfaceName}}."+method, strings.Join(args, ", "), err)
+}`))
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        params,
+		"results":     results,
+		"varArgs":     naming.CallArgs(field, varArgs),
+	}
+
+	resultVars, returnsError := naming.ExtractResultVars(funcType)
+	if !returnsError {
+		if results == nil {
+			return text.ToDecl(fstr.Sprintf(args, `
+func (e *ErrWrap) {{fnName}}({{args}}) ({{results}}) {
+	e.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+		}
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (e *ErrWrap) {{fnName}}({{args}}) ({{results}}) {
+	return e.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+	}
+
+	// context says nothing about the failure, leave it out
+	names := []string{}
+	values := []string{}
+	for n, arg := range varArgs {
+		if n == 0 && code.IsContext(params.List[0].Type) {
+			continue
+		}
+
+		name := code.NodeToString(arg)
+		names = append(names, fmt.Sprintf("%q", name))
+		values = append(values, name)
+	}
+
+	_, errorPos := code.DoesFieldListReturnError(results)
+	wrappedReturns := make([]ast.Expr, len(resultVars))
+	copy(wrappedReturns, resultVars)
+	wrappedReturns[errorPos] = text.ToExpr(fmt.Sprintf(
+		`e.wrap("%s", []string{%s}, []any{%s}, err)`,
+		field.Names[0].Name,
+		strings.Join(names, ", "),
+		strings.Join(values, ", "),
+	))
+
+	args["resultVars"] = resultVars
+	args["wrappedReturns"] = wrappedReturns
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func (e *ErrWrap) {{fnName}}({{args}}) ({{results}}) {
+	{{resultVars}} := e.{{firstLetter}}.{{fnName}}({{varArgs}})
+	if err != nil {
+		return {{wrappedReturns}}
+	}
+
+	return {{resultVars}}
+}`))
+}
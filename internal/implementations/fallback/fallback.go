@@ -0,0 +1,146 @@
+package fallback
+
+import (
+	"fmt"
+	"go/ast"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "fallback"
+}
+
+func (i *Implementator) Description() string {
+	return "Call secondary implementation when primary returns an error"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+
+		primary := code.FieldFromTypeSpec(typeSpec, i.packageName)
+		primary.Name = "primary"
+		secondary := code.FieldFromTypeSpec(typeSpec, i.packageName)
+		secondary.Name = "secondary"
+
+		decls = append(decls, code.Struct(
+			"Fallback",
+			primary,
+			secondary,
+			code.StructField{
+				Name:    "shouldFallback",
+				TypeStr: "func(error) bool",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+		decls = append(decls, failoverFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New(
+		primary, secondary {{interfaceSelector}},
+		shouldFallback func(error) bool,
+	) *Fallback {
+		return &Fallback{
+			primary: primary,
+			secondary: secondary,
+			shouldFallback: shouldFallback,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+// failoverFunction generates check deciding if the error should be handled
+// by secondary, without predicate every error is
+func failoverFunction() ast.Decl {
+	return text.ToDecl(`
+func (f *Fallback) failover(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return f.shouldFallback == nil || f.shouldFallback(err)
+}`)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	args := map[string]any{
+		"fnName":  field.Names[0].Name,
+		"args":    params,
+		"results": results,
+		"varArgs": naming.CallArgs(field, naming.ExtractFuncArgs(field)),
+	}
+
+	resultVars, returnsError := naming.ExtractResultVars(funcType)
+
+	switch {
+	case returnsError:
+		args["resultVars"] = resultVars
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (f *Fallback) {{fnName}}({{args}}) ({{results}}) {
+	{{resultVars}} := f.primary.{{fnName}}({{varArgs}})
+	if !f.failover(err) {
+		return {{resultVars}}
+	}
+
+	return f.secondary.{{fnName}}({{varArgs}})
+}`))
+	case results != nil:
+		return text.ToDecl(fstr.Sprintf(args, `
+func (f *Fallback) {{fnName}}({{args}}) ({{results}}) {
+	return f.primary.{{fnName}}({{varArgs}})
+}`))
+	default:
+		return text.ToDecl(fstr.Sprintf(args, `
+func (f *Fallback) {{fnName}}({{args}}) ({{results}}) {
+	f.primary.{{fnName}}({{varArgs}})
+}`))
+	}
+}
@@ -65,6 +65,7 @@ func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
 			}
 			methodDef := interfaceNode.Methods.List[0]
 			i.validate(methodDef)
+			code.AddPackageNameToFieldList(methodDef.Type.(*ast.FuncType).Params, i.packageName)
 
 			filterFuncsSignature := text.ToExpr(fstr.Sprintf(map[string]any{
 				"params": code.RemoveNames(methodDef.Type.(*ast.FuncType).Params),
@@ -119,7 +120,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 	returnPartArgs := map[string]any{
 		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
 		"fnName":      field.Names[0].Name,
-		"varArgs":     naming.ExtractFuncArgs(field),
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
 	}
 
 	var returnPart string
@@ -143,7 +144,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 		"results":     results,
 		"zeroReturns": zeroReturns,
 		"returnPart":  returnPart,
-		"varArgs":     naming.ExtractFuncArgs(field),
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
 	}, `
 func ({{firstLetter}} *Filter) {{fnName}}({{args}}) ({{results}}) {
 	for _, filter := range {{firstLetter}}.filters {
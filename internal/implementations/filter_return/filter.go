@@ -116,7 +116,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 			i.packageName,
 		),
 		"results":          results,
-		"varArgs":          naming.ExtractFuncArgs(field),
+		"varArgs":          naming.CallArgs(field, naming.ExtractFuncArgs(field)),
 		"resultType":       field.Type.(*ast.FuncType).Results.List[0].Type,
 		"resultVars":       resultVars,
 		"resultVar":        resultVars[0],
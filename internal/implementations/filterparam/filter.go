@@ -138,7 +138,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 		"fnName":           field.Names[0].Name,
 		"params":           params,
 		"results":          field.Type.(*ast.FuncType).Results,
-		"varArgs":          finalParams,
+		"varArgs":          naming.CallArgs(field, finalParams),
 		"paramsType":       paramType,
 		"paramVar":         paramVars[0],
 		"addToFilterered":  addToFiltered,
@@ -0,0 +1,166 @@
+package log
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "log"
+}
+
+func (i *Implementator) Description() string {
+	return "Log calls, their arguments, duration and error using log.Logger"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			i.interfaceName+"Logger",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "logger",
+				TypeStr: "*log.Logger",
+			},
+		))
+		decls = append(decls, i.newWrapperFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWrapperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"interfaceName":     i.interfaceName,
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New{{interfaceName}}({{firstLetter}} {{interfaceSelector}}, logger *log.Logger) *{{interfaceName}}Logger {
+		return &{{interfaceName}}Logger{
+			{{firstLetter}}: {{firstLetter}},
+			logger: logger,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+	resultVars, returnsError := naming.ExtractResultVars(funcType)
+	// locals and the receiver don't shadow the params
+	for n, resultVar := range resultVars {
+		resultVars[n] = ast.NewIdent(naming.LocalName(code.NodeToString(resultVar), varArgs))
+	}
+
+	// context is not worth logging, skip it
+	var loggedArgs string
+	placeholders := []string{}
+	for n, arg := range varArgs {
+		if n == 0 && code.IsContext(params.List[0].Type) {
+			continue
+		}
+
+		loggedArgs += ", " + code.NodeToString(arg)
+		placeholders = append(placeholders, "%v")
+	}
+
+	args := map[string]any{
+		"interfaceName": i.interfaceName,
+		"firstLetter":   unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":        field.Names[0].Name,
+		"args":          params,
+		"results":       results,
+		"varArgs":       naming.CallArgs(field, varArgs),
+		"callFormat": fmt.Sprintf(
+			"%s.%s(%s)",
+			i.interfaceName,
+			field.Names[0].Name,
+			strings.Join(placeholders, ", "),
+		),
+		"loggedArgs": loggedArgs,
+		"l":          naming.LocalName("l", varArgs),
+		"start":      naming.LocalName("start", varArgs),
+	}
+
+	switch {
+	case returnsError:
+		args["resultVars"] = resultVars
+		args["err"] = naming.LocalName("err", varArgs)
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{l}} *{{interfaceName}}Logger) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{resultVars}} := {{l}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	if {{err}} != nil {
+		{{l}}.logger.Printf("{{callFormat}} failed after %s: %v"{{loggedArgs}}, time.Since({{start}}), {{err}})
+		return {{resultVars}}
+	}
+
+	{{l}}.logger.Printf("{{callFormat}} took %s"{{loggedArgs}}, time.Since({{start}}))
+
+	return {{resultVars}}
+}`))
+	case results != nil:
+		args["resultVars"] = resultVars
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{l}} *{{interfaceName}}Logger) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{resultVars}} := {{l}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	{{l}}.logger.Printf("{{callFormat}} took %s"{{loggedArgs}}, time.Since({{start}}))
+
+	return {{resultVars}}
+}`))
+	default:
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{l}} *{{interfaceName}}Logger) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{l}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	{{l}}.logger.Printf("{{callFormat}} took %s"{{loggedArgs}}, time.Since({{start}}))
+}`))
+	}
+}
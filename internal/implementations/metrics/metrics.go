@@ -114,9 +114,10 @@ func (i *Implementator) implementFunction(interfaceName string, field *ast.Field
 	funcName := field.Names[0].Name
 
 	typeDef := &ast.FuncType{
-		Params: &ast.FieldList{
-			List: field.Type.(*ast.FuncType).Params.List,
-		},
+		Params: code.AddPackageNameToFieldList(
+			field.Type.(*ast.FuncType).Params,
+			i.packageName,
+		),
 		Results: &ast.FieldList{},
 	}
 
@@ -137,7 +138,7 @@ func (i *Implementator) implementFunction(interfaceName string, field *ast.Field
 
 	typeDef.Results = code.AddPackageNameToFieldListAndRemoveNames(typeDef.Results, i.packageName)
 
-	returns, returningError := processReturns(typeDef)
+	returns, returningError := naming.ExtractResultVars(typeDef)
 
 	callWrapped := &ast.CallExpr{
 		Fun: &ast.SelectorExpr{
@@ -146,6 +147,9 @@ func (i *Implementator) implementFunction(interfaceName string, field *ast.Field
 		},
 		Args: callArgs,
 	}
+	if naming.IsVariadic(field) {
+		callWrapped.Ellipsis = 1
+	}
 
 	var callStmt ast.Stmt
 
@@ -270,49 +274,3 @@ func (i *Implementator) measuredBody(
 
 	return blockStmt
 }
-
-func processReturns(typeDef *ast.FuncType) ([]ast.Expr, bool) {
-	resultsList := typeDef.Results.List
-	var returningError bool
-
-	var returns []ast.Expr
-	for _, result := range resultsList {
-		switch n := result.Type.(type) {
-		case *ast.Ident:
-			if n.Name == "error" {
-				returningError = true
-			}
-		}
-	}
-
-	var namedReturns int
-
-	if returningError {
-		namedReturns = len(resultsList) - 1
-	} else {
-		namedReturns = len(resultsList)
-	}
-
-	for n, result := range resultsList {
-		isError := false
-
-		switch n := result.Type.(type) {
-		case *ast.Ident:
-			if n.Name == "error" {
-				isError = true
-			}
-		}
-
-		if isError {
-			returns = append(returns, ast.NewIdent("err"))
-		} else {
-			if namedReturns > 1 {
-				returns = append(returns, ast.NewIdent(fmt.Sprintf("result%v", n+1)))
-			} else {
-				returns = append(returns, ast.NewIdent("result"))
-			}
-		}
-	}
-
-	return returns, returningError
-}
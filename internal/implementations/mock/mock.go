@@ -0,0 +1,202 @@
+package mock
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "mock"
+}
+
+func (i *Implementator) Description() string {
+	return "Test double recording calls, with behaviour overridable per method"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			funcFields := []code.StructField{}
+			callsFields := []code.StructField{}
+			methodDecls := []ast.Decl{}
+			for _, methodDef := range interfaceNode.Methods.List {
+				funcType := methodDef.Type.(*ast.FuncType)
+				params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+				results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+				varArgs := naming.ExtractFuncArgs(methodDef)
+
+				funcFields = append(funcFields, code.StructField{
+					Name: funcFieldName(methodDef),
+					TypeSpec: &ast.FuncType{
+						Params:  params,
+						Results: results,
+					},
+				})
+				callsFields = append(callsFields, code.StructField{
+					Name:     callsFieldName(methodDef),
+					TypeSpec: &ast.ArrayType{Elt: ast.NewIdent(i.callStructName(methodDef))},
+				})
+
+				// receiver of the method and its accessor doesn't shadow the params
+				receiver := naming.LocalName("m", varArgs)
+
+				methodDecls = append(methodDecls, i.callStruct(methodDef, params))
+				methodDecls = append(methodDecls, i.implementFunction(methodDef, params, results, varArgs, receiver))
+				methodDecls = append(methodDecls, i.callsAccessor(methodDef, receiver))
+			}
+
+			fields := append(funcFields, code.StructField{
+				Name:    "mu",
+				TypeStr: "sync.Mutex",
+			})
+			fields = append(fields, callsFields...)
+
+			decls = append(decls, code.Struct(i.mockName(), fields...))
+			decls = append(decls, methodDecls...)
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) mockName() string {
+	return i.interfaceName + "Mock"
+}
+
+func (i *Implementator) callStructName(field *ast.Field) string {
+	return i.interfaceName + field.Names[0].Name + "Call"
+}
+
+func funcFieldName(field *ast.Field) string {
+	return field.Names[0].Name + "Func"
+}
+
+func callsFieldName(field *ast.Field) string {
+	return naming.LowercaseFirstLetter(field.Names[0].Name) + "Calls"
+}
+
+// callStruct generates struct holding arguments of a single call, with
+// exported field for every param
+func (i *Implementator) callStruct(field *ast.Field, params *ast.FieldList) ast.Decl {
+	fields := []code.StructField{}
+	for _, param := range params.List {
+		paramType := param.Type
+		if variadic, ok := paramType.(*ast.Ellipsis); ok {
+			paramType = &ast.ArrayType{Elt: variadic.Elt}
+		}
+
+		for _, name := range param.Names {
+			fields = append(fields, code.StructField{
+				Name:     naming.UppercaseFirstLetter(name.Name),
+				TypeSpec: paramType,
+			})
+		}
+	}
+
+	return code.Struct(i.callStructName(field), fields...)
+}
+
+func (i *Implementator) implementFunction(
+	field *ast.Field,
+	params, results *ast.FieldList,
+	varArgs []ast.Expr,
+	receiver string,
+) ast.Decl {
+	recorded := []string{}
+	for _, arg := range varArgs {
+		name := code.NodeToString(arg)
+		recorded = append(recorded, fmt.Sprintf("%s: %s", naming.UppercaseFirstLetter(name), name))
+	}
+
+	args := map[string]any{
+		"mockName":   i.mockName(),
+		"fnName":     field.Names[0].Name,
+		"args":       params,
+		"results":    results,
+		"varArgs":    naming.CallArgs(field, varArgs),
+		"funcField":  funcFieldName(field),
+		"callsField": callsFieldName(field),
+		"callStruct": i.callStructName(field),
+		"recorded":   strings.Join(recorded, ", "),
+		"m":          receiver,
+	}
+
+	if results == nil {
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{m}} *{{mockName}}) {{fnName}}({{args}}) ({{results}}) {
+	{{m}}.mu.Lock()
+	{{m}}.{{callsField}} = append({{m}}.{{callsField}}, {{callStruct}}{ {{recorded}} })
+	{{m}}.mu.Unlock()
+
+	if {{m}}.{{funcField}} != nil {
+		{{m}}.{{funcField}}({{varArgs}})
+	}
+}`))
+	}
+
+	zeroReturns := []ast.Expr{}
+	for _, r := range results.List {
+		zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+	}
+	args["zeroReturns"] = zeroReturns
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func ({{m}} *{{mockName}}) {{fnName}}({{args}}) ({{results}}) {
+	{{m}}.mu.Lock()
+	{{m}}.{{callsField}} = append({{m}}.{{callsField}}, {{callStruct}}{ {{recorded}} })
+	{{m}}.mu.Unlock()
+
+	if {{m}}.{{funcField}} == nil {
+		return {{zeroReturns}}
+	}
+
+	return {{m}}.{{funcField}}({{varArgs}})
+}`))
+}
+
+func (i *Implementator) callsAccessor(field *ast.Field, receiver string) ast.Decl {
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"m":          receiver,
+		"mockName":   i.mockName(),
+		"fnName":     field.Names[0].Name,
+		"callsField": callsFieldName(field),
+		"callStruct": i.callStructName(field),
+	}, `
+func ({{m}} *{{mockName}}) {{fnName}}Calls() []{{callStruct}} {
+	{{m}}.mu.Lock()
+	defer {{m}}.mu.Unlock()
+
+	return append([]{{callStruct}}(nil), {{m}}.{{callsField}}...)
+}`))
+}
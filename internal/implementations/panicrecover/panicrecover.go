@@ -0,0 +1,171 @@
+package panicrecover
+
+import (
+	"fmt"
+	"go/ast"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "recover"
+}
+
+func (i *Implementator) Description() string {
+	return "Recover panics and return them as errors"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, panicErrorDecls()...)
+		decls = append(decls, code.Struct(
+			"Recover",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "onPanic",
+				TypeStr: "func(*PanicError)",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func panicErrorDecls() []ast.Decl {
+	return []ast.Decl{
+		code.Struct(
+			"PanicError",
+			code.StructField{
+				Name:    "Method",
+				TypeStr: "string",
+			},
+			code.StructField{
+				Name:    "Value",
+				TypeStr: "any",
+			},
+			code.StructField{
+				Name:    "Stack",
+				TypeStr: "[]byte",
+			},
+		),
+		text.ToDecl(`
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%s panicked: %v\n%s", e.Method, e.Value, e.Stack)
+}`),
+	}
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New({{firstLetter}} {{interfaceSelector}}, onPanic func(*PanicError)) *Recover {
+		return &Recover{
+			{{firstLetter}}: {{firstLetter}},
+			onPanic: onPanic,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	validate(field)
+
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	resultVars, _ := naming.ExtractResultVars(funcType)
+
+	// results are named so the deferred function can overwrite them
+	namedResults := &ast.FieldList{}
+	zeroReturns := []ast.Expr{}
+	for n, r := range results.List {
+		namedResults.List = append(namedResults.List, &ast.Field{
+			Names: []*ast.Ident{resultVars[n].(*ast.Ident)},
+			Type:  r.Type,
+		})
+		zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+	}
+
+	_, errorPos := code.DoesFieldListReturnError(results)
+	zeroReturns[errorPos] = ast.NewIdent("panicErr")
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"method":      fmt.Sprintf("%s.%s", i.interfaceName, field.Names[0].Name),
+		"args":        params,
+		"results":     namedResults,
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
+		"resultVars":  resultVars,
+		"zeroReturns": zeroReturns,
+	}, `
+func (r *Recover) {{fnName}}({{args}}) ({{results}}) {
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+
+		panicErr := &PanicError{
+			Method: "{{method}}",
+			Value: value,
+			Stack: debug.Stack(),
+		}
+		if r.onPanic != nil {
+			r.onPanic(panicErr)
+		}
+
+		{{resultVars}} = {{zeroReturns}}
+	}()
+
+	return r.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+}
+
+func validate(field *ast.Field) {
+	returnsError, _ := code.DoesFieldReturnError(field)
+	if !returnsError {
+		panic("last return value must be an error")
+	}
+}
@@ -0,0 +1,343 @@
+package parallel
+
+import (
+	"fmt"
+	"go/ast"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "parallel"
+}
+
+func (i *Implementator) Description() string {
+	return "Split collection passed as parameter into chunks and process them concurrently with bounded number of workers"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			"Parallel",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "chunkSize",
+				TypeStr: "int",
+			},
+			code.StructField{
+				Name:    "workers",
+				TypeStr: "int",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			parallelized := 0
+			for _, methodDef := range interfaceNode.Methods.List {
+				decl, ok := i.implementFunction(methodDef)
+				if ok {
+					parallelized++
+				}
+
+				decls = append(decls, decl)
+			}
+
+			if parallelized == 0 {
+				panic("expected at least one method with enumerable as first parameter")
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New({{firstLetter}} {{interfaceSelector}}, chunkSize, workers int) *Parallel {
+		if chunkSize < 1 {
+			chunkSize = 1
+		}
+		if workers < 1 {
+			workers = 1
+		}
+		return &Parallel{
+			{{firstLetter}}: {{firstLetter}},
+			chunkSize: chunkSize,
+			workers: workers,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+// implementFunction returns false as the second value if the method
+// can't be parallelized and was only forwarded
+func (i *Implementator) implementFunction(field *ast.Field) (ast.Decl, bool) {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        params,
+		"results":     results,
+	}
+
+	collectionPos := 0
+	if len(params.List) > 0 && code.IsContext(params.List[0].Type) {
+		collectionPos = 1
+	}
+
+	if len(varArgs) <= collectionPos || !canParallelize(params.List[collectionPos].Type, results) {
+		args["varArgs"] = naming.CallArgs(field, varArgs)
+		args["p"] = naming.LocalName("p", varArgs)
+		return i.forwardFunction(args, results != nil), false
+	}
+
+	collectionType := params.List[collectionPos].Type
+
+	// locals and the receiver don't shadow the params
+	locals := map[string]any{}
+	for _, name := range []string{
+		"p", "chunks", "chunk", "results", "errs", "merged", "sem", "wg", "n",
+		"start", "end", "key", "item", "result",
+	} {
+		locals[name] = naming.LocalName(name, varArgs)
+	}
+
+	chunkArgs := make([]ast.Expr, len(varArgs))
+	copy(chunkArgs, varArgs)
+	chunkArgs[collectionPos] = ast.NewIdent(locals["chunk"].(string))
+
+	returnsError, _ := code.DoesFieldListReturnError(results)
+
+	var resultType ast.Expr
+	if results != nil && code.IsEnumerable(results.List[0].Type) {
+		resultType = results.List[0].Type
+	}
+
+	var declareResults, assignResults, mergeResults, returnResults string
+
+	resultsVar, errs, n, merged := locals["results"], locals["errs"], locals["n"], locals["merged"]
+
+	switch {
+	case resultType != nil && returnsError:
+		assignResults = fmt.Sprintf("%s[%s], %s[%s] = ", resultsVar, n, errs, n)
+		returnResults = fmt.Sprintf("return %s, errors.Join(%s...)", merged, errs)
+	case resultType != nil:
+		assignResults = fmt.Sprintf("%s[%s] = ", resultsVar, n)
+		returnResults = fmt.Sprintf("return %s", merged)
+	case returnsError:
+		assignResults = fmt.Sprintf("%s[%s] = ", errs, n)
+		returnResults = fmt.Sprintf("return errors.Join(%s...)", errs)
+	}
+
+	if resultType != nil {
+		declareResults += fstr.Sprintf(map[string]any{
+			"resultType": resultType,
+			"results":    locals["results"],
+			"chunks":     locals["chunks"],
+		}, "{{results}} := make([]{{resultType}}, len({{chunks}}))\n")
+		mergeResults = fstr.Sprintf(map[string]any{
+			"resultType": resultType,
+			"merge":      merge(resultType, locals),
+			"merged":     locals["merged"],
+			"result":     locals["result"],
+			"results":    locals["results"],
+		}, `
+	{{merged}} := {{resultType}}{}
+	for _, {{result}} := range {{results}} {
+		{{merge}}
+	}`)
+	}
+
+	if returnsError {
+		declareResults += fstr.Sprintf(map[string]any{
+			"errs":   locals["errs"],
+			"chunks": locals["chunks"],
+		}, "{{errs}} := make([]error, len({{chunks}}))\n")
+	}
+
+	args["collectionType"] = collectionType
+	args["split"] = split(collectionType, varArgs[collectionPos], locals)
+	args["chunkArgs"] = naming.CallArgs(field, chunkArgs)
+	args["declareResults"] = declareResults
+	args["assignResults"] = assignResults
+	args["mergeResults"] = mergeResults
+	args["returnResults"] = returnResults
+
+	for _, name := range []string{"p", "chunks", "chunk", "sem", "wg", "n"} {
+		args[name] = locals[name]
+	}
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func ({{p}} *Parallel) {{fnName}}({{args}}) ({{results}}) {
+	{{split}}
+
+	{{declareResults}}
+	{{sem}} := make(chan struct{}, {{p}}.workers)
+	var {{wg}} sync.WaitGroup
+
+	for {{n}}, {{chunk}} := range {{chunks}} {
+		{{wg}}.Add(1)
+		{{sem}} <- struct{}{}
+
+		go func({{n}} int, {{chunk}} {{collectionType}}) {
+			defer {{wg}}.Done()
+			defer func() { <-{{sem}} }()
+
+			{{assignResults}}{{p}}.{{firstLetter}}.{{fnName}}({{chunkArgs}})
+		}({{n}}, {{chunk}})
+	}
+
+	{{wg}}.Wait()
+	{{mergeResults}}
+
+	{{returnResults}}
+}`)), true
+}
+
+func (i *Implementator) forwardFunction(args map[string]any, hasResults bool) ast.Decl {
+	if !hasResults {
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{p}} *Parallel) {{fnName}}({{args}}) ({{results}}) {
+	{{p}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+	}
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func ({{p}} *Parallel) {{fnName}}({{args}}) ({{results}}) {
+	return {{p}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+}
+
+// canParallelize checks if param can be split into chunks and if results
+// of calls for each chunk can be merged
+func canParallelize(param ast.Expr, results *ast.FieldList) bool {
+	switch param.(type) {
+	case *ast.ArrayType, *ast.MapType:
+	default:
+		return false
+	}
+
+	if results == nil {
+		return true
+	}
+
+	returnsError, _ := code.DoesFieldListReturnError(results)
+
+	switch len(results.List) {
+	case 1:
+		return returnsError || isMergeable(results.List[0].Type)
+	case 2:
+		return returnsError && isMergeable(results.List[0].Type)
+	default:
+		return false
+	}
+}
+
+func isMergeable(t ast.Expr) bool {
+	switch t.(type) {
+	case *ast.ArrayType, *ast.MapType:
+		return true
+	default:
+		return false
+	}
+}
+
+func split(t ast.Expr, collection ast.Expr, locals map[string]any) string {
+	env := map[string]any{
+		"collection": collection,
+		"type":       t,
+	}
+	for _, name := range []string{"p", "chunks"} {
+		env[name] = locals[name]
+	}
+
+	switch t.(type) {
+	case *ast.ArrayType:
+		env["start"] = locals["start"]
+		env["end"] = locals["end"]
+
+		return fstr.Sprintf(env, `
+	{{chunks}} := []{{type}}{}
+	for {{start}} := 0; {{start}} < len({{collection}}); {{start}} += {{p}}.chunkSize {
+		{{end}} := {{start}} + {{p}}.chunkSize
+		if {{end}} > len({{collection}}) {
+			{{end}} = len({{collection}})
+		}
+
+		{{chunks}} = append({{chunks}}, {{collection}}[{{start}}:{{end}}])
+	}`)
+	case *ast.MapType:
+		env["chunk"] = locals["chunk"]
+		env["key"] = locals["key"]
+		env["item"] = locals["item"]
+
+		return fstr.Sprintf(env, `
+	{{chunks}} := []{{type}}{}
+	{{chunk}} := {{type}}{}
+	for {{key}}, {{item}} := range {{collection}} {
+		{{chunk}}[{{key}}] = {{item}}
+		if len({{chunk}}) == {{p}}.chunkSize {
+			{{chunks}} = append({{chunks}}, {{chunk}})
+			{{chunk}} = {{type}}{}
+		}
+	}
+
+	if len({{chunk}}) > 0 {
+		{{chunks}} = append({{chunks}}, {{chunk}})
+	}`)
+	default:
+		panic("unsupported type")
+	}
+}
+
+func merge(t ast.Expr, locals map[string]any) string {
+	switch t.(type) {
+	case *ast.ArrayType:
+		return fmt.Sprintf("%s = append(%s, %s...)", locals["merged"], locals["merged"], locals["result"])
+	case *ast.MapType:
+		return fmt.Sprintf(`for %s, %s := range %s {
+			%s[%s] = %s
+		}`, locals["key"], locals["item"], locals["result"], locals["merged"], locals["key"], locals["item"])
+	default:
+		panic("unsupported type")
+	}
+}
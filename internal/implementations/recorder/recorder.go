@@ -0,0 +1,457 @@
+package recorder
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "recorder"
+}
+
+func (i *Implementator) Description() string {
+	return "Record calls to JSON lines file and replay them without the implementation, " +
+		"failures that can't be returned are reported by Err, params of types not in the input are not recorded"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, recordingStruct())
+		decls = append(decls, code.Struct(
+			"Recorder",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "mu",
+				TypeStr: "sync.Mutex",
+			},
+			code.StructField{
+				Name:    "encoder",
+				TypeStr: "*json.Encoder",
+			},
+			code.StructField{
+				Name:    "recordings",
+				TypeStr: "map[string][]Recording",
+			},
+			code.StructField{
+				Name:    "errs",
+				TypeStr: "[]error",
+			},
+		))
+		decls = append(decls, i.newRecorderFunction())
+		decls = append(decls, newReplayerFunction())
+		decls = append(decls, errFunction())
+		decls = append(decls, failFunction())
+		decls = append(decls, recordFunction())
+		decls = append(decls, replayFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+// recordingStruct is built from a regular string, struct tags need backticks
+func recordingStruct() ast.Decl {
+	return text.ToDecl("type Recording struct {\n" +
+		"Method string `json:\"method\"`\n" +
+		"Args json.RawMessage `json:\"args\"`\n" +
+		"Results []json.RawMessage `json:\"results,omitempty\"`\n" +
+		"Error string `json:\"error,omitempty\"`\n" +
+		"}")
+}
+
+func (i *Implementator) newRecorderFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func NewRecorder({{firstLetter}} {{interfaceSelector}}, w io.Writer) *Recorder {
+		return &Recorder{
+			{{firstLetter}}: {{firstLetter}},
+			encoder: json.NewEncoder(w),
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func newReplayerFunction() ast.Decl {
+	return text.ToDecl(`
+func NewReplayer(reader io.Reader) (*Recorder, error) {
+	recordings := map[string][]Recording{}
+
+	decoder := json.NewDecoder(reader)
+	for {
+		var recording Recording
+		err := decoder.Decode(&recording)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding recording: %w", err)
+		}
+
+		key := recording.Method + string(recording.Args)
+		recordings[key] = append(recordings[key], recording)
+	}
+
+	return &Recorder{
+		recordings: recordings,
+	}, nil
+}`)
+}
+
+// errFunction generates access to failures the methods couldn't return,
+// recording ones and replaying of methods without error result
+func errFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return errors.Join(r.errs...)
+}`)
+}
+
+func failFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Recorder) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.errs = append(r.errs, err)
+}`)
+}
+
+// recordFunction generates recording of the call, the wrapped call already
+// happened, so failures are only reported and the results are returned
+func recordFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Recorder) record(method string, args, results []any, err error) {
+	recording := Recording{
+		Method: method,
+	}
+
+	var marshalErr error
+	recording.Args, marshalErr = json.Marshal(args)
+	if marshalErr != nil {
+		r.fail(fmt.Errorf("recording %s args: %w", method, marshalErr))
+		return
+	}
+
+	for _, result := range results {
+		raw, marshalErr := json.Marshal(result)
+		if marshalErr != nil {
+			r.fail(fmt.Errorf("recording %s results: %w", method, marshalErr))
+			return
+		}
+
+		recording.Results = append(recording.Results, raw)
+	}
+
+	if err != nil {
+		recording.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if encodeErr := r.encoder.Encode(recording); encodeErr != nil {
+		r.errs = append(r.errs, fmt.Errorf("recording %s: %w", method, encodeErr))
+	}
+}`)
+}
+
+// replayFunction generates lookup of recorded call, identical calls are
+// replayed in recorded order, the last one is repeated once others are used
+func replayFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Recorder) replay(method string, args, results []any) error {
+	encodedArgs, err := json.Marshal(args)
+	if err != nil {
+		return fmt.Errorf("replaying %s: %w", method, err)
+	}
+
+	key := method + string(encodedArgs)
+
+	r.mu.Lock()
+	recordings := r.recordings[key]
+	if len(recordings) > 1 {
+		r.recordings[key] = recordings[1:]
+	}
+	r.mu.Unlock()
+
+	if len(recordings) == 0 {
+		return fmt.Errorf("replaying %s: no recording for args %s", method, encodedArgs)
+	}
+
+	recording := recordings[0]
+	if len(recording.Results) != len(results) {
+		return fmt.Errorf("replaying %s: recorded %d results, expected %d", method, len(recording.Results), len(results))
+	}
+
+	for n, result := range results {
+		if err := json.Unmarshal(recording.Results[n], result); err != nil {
+			return fmt.Errorf("replaying %s results: %w", method, err)
+		}
+	}
+
+	if recording.Error != "" {
+		return errors.New(recording.Error)
+	}
+
+	return nil
+}`)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	// told before qualification, types of the input resolve by identifiers
+	encodableParams := []bool{}
+	for _, param := range funcType.Params.List {
+		for range max(len(param.Names), 1) {
+			encodableParams = append(encodableParams, encodable(param.Type, false))
+		}
+	}
+
+	if funcType.Results != nil {
+		for _, result := range funcType.Results.List {
+			if !encodable(result.Type, true) {
+				panic(fmt.Sprintf(
+					"%s returns %s which can't be recorded",
+					field.Names[0].Name,
+					code.NodeToString(result.Type),
+				))
+			}
+		}
+	}
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+
+	// context, funcs and channels can't be serialised, neither can types
+	// not declared in the input be told apart from them, calls are told
+	// apart by the other args
+	recordedArgs := []ast.Expr{}
+	for n, arg := range varArgs {
+		if n == 0 && code.IsContext(params.List[0].Type) {
+			continue
+		}
+
+		if !encodableParams[n] {
+			continue
+		}
+
+		recordedArgs = append(recordedArgs, arg)
+	}
+
+	resultVars, returnsError := naming.ExtractResultVars(funcType)
+
+	declarations := []string{}
+	recordedResults := []string{}
+	replayedResults := []string{}
+	for n, result := range resultVars {
+		name := code.NodeToString(result)
+		if name == "err" {
+			continue
+		}
+
+		declarations = append(declarations, fmt.Sprintf(
+			"%s %s",
+			name,
+			code.NodeToString(results.List[n].Type),
+		))
+		recordedResults = append(recordedResults, name)
+		replayedResults = append(replayedResults, "&"+name)
+	}
+
+	args := map[string]any{
+		"firstLetter":     unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":          field.Names[0].Name,
+		"args":            params,
+		"results":         results,
+		"varArgs":         naming.CallArgs(field, varArgs),
+		"recordedArgs":    recordedArgs,
+		"recordedResults": sliceOrNil(recordedResults),
+		"replayedResults": sliceOrNil(replayedResults),
+	}
+
+	switch {
+	case returnsError && len(declarations) == 0:
+		return text.ToDecl(fstr.Sprintf(args, `
+func (r *Recorder) {{fnName}}({{args}}) ({{results}}) {
+	if r.recordings != nil {
+		return r.replay("{{fnName}}", []any{ {{recordedArgs}} }, {{replayedResults}})
+	}
+
+	err := r.{{firstLetter}}.{{fnName}}({{varArgs}})
+	r.record("{{fnName}}", []any{ {{recordedArgs}} }, {{recordedResults}}, err)
+
+	return err
+}`))
+	case returnsError:
+		args["declarations"] = varBlock(declarations)
+		args["resultVars"] = resultVars
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (r *Recorder) {{fnName}}({{args}}) ({{results}}) {
+	if r.recordings != nil {
+		{{declarations}}
+		err := r.replay("{{fnName}}", []any{ {{recordedArgs}} }, {{replayedResults}})
+
+		return {{resultVars}}
+	}
+
+	{{resultVars}} := r.{{firstLetter}}.{{fnName}}({{varArgs}})
+	r.record("{{fnName}}", []any{ {{recordedArgs}} }, {{recordedResults}}, err)
+
+	return {{resultVars}}
+}`))
+	case results != nil:
+		args["declarations"] = varBlock(declarations)
+		args["resultVars"] = resultVars
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (r *Recorder) {{fnName}}({{args}}) ({{results}}) {
+	if r.recordings != nil {
+		{{declarations}}
+		if err := r.replay("{{fnName}}", []any{ {{recordedArgs}} }, {{replayedResults}}); err != nil {
+			r.fail(err)
+		}
+
+		return {{resultVars}}
+	}
+
+	{{resultVars}} := r.{{firstLetter}}.{{fnName}}({{varArgs}})
+	r.record("{{fnName}}", []any{ {{recordedArgs}} }, {{recordedResults}}, nil)
+
+	return {{resultVars}}
+}`))
+	default:
+		return text.ToDecl(fstr.Sprintf(args, `
+func (r *Recorder) {{fnName}}({{args}}) ({{results}}) {
+	if r.recordings != nil {
+		if err := r.replay("{{fnName}}", []any{ {{recordedArgs}} }, {{replayedResults}}); err != nil {
+			r.fail(err)
+		}
+
+		return
+	}
+
+	r.{{firstLetter}}.{{fnName}}({{varArgs}})
+	r.record("{{fnName}}", []any{ {{recordedArgs}} }, {{recordedResults}}, nil)
+}`))
+	}
+}
+
+// encodable tells whether values of the type can be encoded to JSON, types
+// declared in the source package but not in the input are taken as
+// encodable when unknown is true
+func encodable(t ast.Expr, unknown bool) bool {
+	switch t := t.(type) {
+	case *ast.FuncType, *ast.ChanType:
+		return false
+	case *ast.StarExpr:
+		return encodable(t.X, unknown)
+	case *ast.Ellipsis:
+		return encodable(t.Elt, unknown)
+	case *ast.ArrayType:
+		return encodable(t.Elt, unknown)
+	case *ast.MapType:
+		return encodable(t.Value, unknown)
+	case *ast.IndexExpr:
+		return encodable(t.X, unknown)
+	case *ast.IndexListExpr:
+		return encodable(t.X, unknown)
+	case *ast.Ident:
+		resolved, ok := code.TypeOf(t)
+		if !ok {
+			return unknown
+		}
+
+		return encodableType(resolved, map[types.Type]bool{})
+	default:
+		return true
+	}
+}
+
+// encodableType checks the underlying type of a type declared in the
+// input, named types seen are not checked again, they can be recursive
+func encodableType(t types.Type, seen map[types.Type]bool) bool {
+	if seen[t] {
+		return true
+	}
+
+	seen[t] = true
+
+	switch u := t.Underlying().(type) {
+	case *types.Signature, *types.Chan:
+		return false
+	case *types.Pointer:
+		return encodableType(u.Elem(), seen)
+	case *types.Slice:
+		return encodableType(u.Elem(), seen)
+	case *types.Array:
+		return encodableType(u.Elem(), seen)
+	case *types.Map:
+		return encodableType(u.Elem(), seen)
+	default:
+		return true
+	}
+}
+
+func sliceOrNil(values []string) string {
+	if len(values) == 0 {
+		return "nil"
+	}
+
+	return "[]any{" + strings.Join(values, ", ") + "}"
+}
+
+func varBlock(declarations []string) string {
+	if len(declarations) == 1 {
+		return "var " + declarations[0]
+	}
+
+	return "var (\n" + strings.Join(declarations, "\n") + "\n)"
+}
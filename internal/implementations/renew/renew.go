@@ -0,0 +1,261 @@
+package renew
+
+import (
+	"fmt"
+	"go/ast"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+	methodDef     *ast.Field
+	valueType     ast.Expr
+	argIsContext  bool
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "renew"
+}
+
+func (i *Implementator) Description() string {
+	return "Keep expiring value (for example token) in memory and renew it in the background before it expires"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			i.validate(interfaceNode)
+
+			i.interfaceName = typeSpec.Name.Name
+			i.valueType = code.PossiblyAddPackageName(
+				i.packageName,
+				i.methodDef.Type.(*ast.FuncType).Results.List[0].Type,
+			)
+
+			decls = append(decls, code.Struct(
+				"Renew",
+				code.FieldFromTypeSpec(typeSpec, i.packageName),
+				code.StructField{
+					Name:     "expiresAt",
+					TypeSpec: i.expiresAtSignature(),
+				},
+				code.StructField{
+					Name:    "refreshBefore",
+					TypeStr: "time.Duration",
+				},
+				code.StructField{
+					Name:    "mu",
+					TypeStr: "sync.Mutex",
+				},
+				// named after neither the type nor its package, which
+				// generated code uses
+				code.StructField{
+					Name:     "value",
+					TypeSpec: i.valueType,
+				},
+				code.StructField{
+					Name:    "validUntil",
+					TypeStr: "time.Time",
+				},
+				code.StructField{
+					Name:    "err",
+					TypeStr: "error",
+				},
+				code.StructField{
+					Name:    "refreshing",
+					TypeStr: "chan struct{}",
+				},
+			))
+			decls = append(decls, i.newWraperFunction())
+			decls = append(decls, i.startRefreshFunction())
+			decls = append(decls, i.refreshFunction())
+			decls = append(decls, i.implementFunction())
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) expiresAtSignature() ast.Expr {
+	return text.ToExpr(fstr.Sprintf(map[string]any{
+		"valueType": i.valueType,
+	}, "func({{valueType}}) time.Time"))
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+		"expiresAt":         i.expiresAtSignature(),
+	}, `
+	func New(
+		{{firstLetter}} {{interfaceSelector}},
+		expiresAt {{expiresAt}},
+		refreshBefore time.Duration,
+	) *Renew {
+		return &Renew{
+			{{firstLetter}}: {{firstLetter}},
+			expiresAt: expiresAt,
+			refreshBefore: refreshBefore,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) startRefreshFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Renew) startRefresh() chan struct{} {
+	if r.refreshing != nil {
+		return r.refreshing
+	}
+
+	r.refreshing = make(chan struct{})
+	go r.refresh(r.refreshing)
+
+	return r.refreshing
+}`)
+}
+
+func (i *Implementator) refreshFunction() ast.Decl {
+	var callArgs string
+	if i.argIsContext {
+		callArgs = "context.Background()"
+	}
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      i.methodDef.Names[0].Name,
+		"callArgs":    callArgs,
+	}, `
+func (r *Renew) refresh(done chan struct{}) {
+	value, err := r.{{firstLetter}}.{{fnName}}({{callArgs}})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	defer close(done)
+
+	r.refreshing = nil
+	r.err = err
+	if err != nil {
+		return
+	}
+
+	r.value = value
+	r.validUntil = r.expiresAt(value)
+
+	wait := time.Until(r.validUntil) - r.refreshBefore
+	if wait > 0 {
+		time.AfterFunc(wait, func() {
+			r.mu.Lock()
+			r.startRefresh()
+			r.mu.Unlock()
+		})
+	}
+}`))
+}
+
+func (i *Implementator) implementFunction() ast.Decl {
+	funcType := i.methodDef.Type.(*ast.FuncType)
+	varArgs := naming.ExtractFuncArgs(i.methodDef)
+
+	var wait string
+	if i.argIsContext {
+		wait = fstr.Sprintf(map[string]any{
+			"ctx":       varArgs[0],
+			"zeroValue": code.ZeroValue(i.valueType),
+		}, `
+	select {
+	case <-done:
+	case <-{{ctx}}.Done():
+		return {{zeroValue}}, {{ctx}}.Err()
+	}`)
+	} else {
+		wait = "<-done"
+	}
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"fnName":    i.methodDef.Names[0].Name,
+		"args":      funcType.Params,
+		"valueType": i.valueType,
+		"zeroValue": code.ZeroValue(i.valueType),
+		"wait":      wait,
+	}, `
+func (r *Renew) {{fnName}}({{args}}) ({{valueType}}, error) {
+	r.mu.Lock()
+	if time.Now().Before(r.validUntil) {
+		value := r.value
+		if time.Until(r.validUntil) < r.refreshBefore {
+			r.startRefresh()
+		}
+		r.mu.Unlock()
+
+		return value, nil
+	}
+
+	done := r.startRefresh()
+	r.mu.Unlock()
+
+	{{wait}}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return {{zeroValue}}, r.err
+	}
+
+	return r.value, nil
+}`))
+}
+
+func (i *Implementator) validate(interfaceNode *ast.InterfaceType) {
+	if len(interfaceNode.Methods.List) != 1 {
+		panic("interface should have only one method")
+	}
+
+	i.methodDef = interfaceNode.Methods.List[0]
+	funcType := i.methodDef.Type.(*ast.FuncType)
+
+	if funcType.Results == nil || len(funcType.Results.List) != 2 {
+		panic("method should have two returns")
+	}
+
+	if !code.IsError(funcType.Results.List[1].Type) {
+		panic("last return value must be an error")
+	}
+
+	args := funcType.Params.List
+	if len(args) == 1 {
+		i.argIsContext = code.IsContext(args[0].Type)
+	}
+
+	if !(len(args) == 0 || i.argIsContext) {
+		panic("method should have no parameters other than context")
+	}
+}
@@ -0,0 +1,212 @@
+package retry
+
+import (
+	"fmt"
+	"go/ast"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "retry"
+}
+
+func (i *Implementator) Description() string {
+	return "Retry calls that return an error using exponential backoff with jitter"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			"Retry",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "maxAttempts",
+				TypeStr: "int",
+			},
+			code.StructField{
+				Name:    "baseDelay",
+				TypeStr: "time.Duration",
+			},
+			code.StructField{
+				Name:    "maxDelay",
+				TypeStr: "time.Duration",
+			},
+			code.StructField{
+				Name:    "isRetryable",
+				TypeStr: "func(error) bool",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+		decls = append(decls, i.backoffFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			validate(interfaceNode)
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New(
+		{{firstLetter}} {{interfaceSelector}},
+		maxAttempts int,
+		baseDelay, maxDelay time.Duration,
+		isRetryable func(error) bool,
+	) *Retry {
+		if isRetryable == nil {
+			isRetryable = func(error) bool { return true }
+		}
+
+		return &Retry{
+			{{firstLetter}}: {{firstLetter}},
+			maxAttempts: maxAttempts,
+			baseDelay: baseDelay,
+			maxDelay: maxDelay,
+			isRetryable: isRetryable,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) backoffFunction() ast.Decl {
+	return text.ToDecl(`
+func (r *Retry) backoff(attempt int) time.Duration {
+	delay := r.baseDelay << (attempt - 1)
+	if delay <= 0 || delay > r.maxDelay {
+		delay = r.maxDelay
+	}
+
+	half := int64(delay / 2)
+
+	return time.Duration(half + rand.Int63n(half+1))
+}`)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        params,
+		"results":     results,
+		"varArgs":     naming.CallArgs(field, varArgs),
+	}
+
+	returnsError, _ := code.DoesFieldListReturnError(results)
+	if !returnsError {
+		var t string
+		if results != nil {
+			t = `
+func (r *Retry) {{fnName}}({{args}}) ({{results}}) {
+	return r.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`
+		} else {
+			t = `
+func (r *Retry) {{fnName}}({{args}}) ({{results}}) {
+	r.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`
+		}
+
+		return text.ToDecl(fstr.Sprintf(args, t))
+	}
+
+	resultVars, _ := naming.ExtractResultVars(funcType)
+	args["resultVars"] = resultVars
+
+	if len(params.List) > 0 && code.IsContext(params.List[0].Type) {
+		zeroReturns := []ast.Expr{}
+		for _, r := range results.List {
+			zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+		}
+		zeroReturns[len(zeroReturns)-1] = text.ToExpr(
+			fmt.Sprintf("%s.Err()", code.NodeToString(varArgs[0])),
+		)
+
+		args["ctx"] = varArgs[0]
+		args["zeroReturns"] = zeroReturns
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func (r *Retry) {{fnName}}({{args}}) ({{results}}) {
+	for attempt := 1; ; attempt++ {
+		{{resultVars}} := r.{{firstLetter}}.{{fnName}}({{varArgs}})
+		if err == nil || attempt >= r.maxAttempts || !r.isRetryable(err) {
+			return {{resultVars}}
+		}
+
+		select {
+		case <-{{ctx}}.Done():
+			return {{zeroReturns}}
+		case <-time.After(r.backoff(attempt)):
+		}
+	}
+}`))
+	}
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func (r *Retry) {{fnName}}({{args}}) ({{results}}) {
+	for attempt := 1; ; attempt++ {
+		{{resultVars}} := r.{{firstLetter}}.{{fnName}}({{varArgs}})
+		if err == nil || attempt >= r.maxAttempts || !r.isRetryable(err) {
+			return {{resultVars}}
+		}
+
+		time.Sleep(r.backoff(attempt))
+	}
+}`))
+}
+
+func validate(interfaceNode *ast.InterfaceType) {
+	for _, methodDef := range interfaceNode.Methods.List {
+		returnsError, _ := code.DoesFieldReturnError(methodDef)
+		if returnsError {
+			return
+		}
+	}
+
+	panic("expected at least one method returning an error")
+}
@@ -11,24 +11,63 @@ import (
 	"github.com/relardev/go-pattern-implement/internal/text"
 )
 
+type Mode int
+
+const (
+	ModeBasic Mode = iota
+	ModeWithCancel
+	ModeWithWaitGroup
+	ModeWithWaitGroupAndCancel
+)
+
 type Implementator struct {
 	err           error
 	packageName   string
 	interfaceName string
+	mode          Mode
 }
 
-func New(sourcePackageName string) *Implementator {
+func New(sourcePackageName string, m Mode) *Implementator {
 	return &Implementator{
 		packageName: sourcePackageName,
+		mode:        m,
 	}
 }
 
 func (i *Implementator) Name() string {
-	return "semaphore"
+	switch i.mode {
+	case ModeWithCancel:
+		return "semaphore-cancel"
+	case ModeWithWaitGroup:
+		return "semaphore-waitgroup"
+	case ModeWithWaitGroupAndCancel:
+		return "semaphore-waitgroup-cancel"
+	default:
+		return "semaphore"
+	}
 }
 
 func (i *Implementator) Description() string {
-	return "Simple semaphore implementation"
+	switch i.mode {
+	case ModeWithCancel:
+		return "Semaphore implementation that stops waiting for a slot when context is cancelled"
+	case ModeWithWaitGroup:
+		return "Semaphore implementation with Shutdown that waits for running calls to finish, " +
+			"later calls return ErrShutdown or panic with it when they return no error"
+	case ModeWithWaitGroupAndCancel:
+		return "Semaphore implementation with Shutdown, stops waiting for a slot when context is cancelled, " +
+			"later calls return ErrShutdown"
+	default:
+		return "Simple semaphore implementation"
+	}
+}
+
+func (i *Implementator) cancelable() bool {
+	return i.mode == ModeWithCancel || i.mode == ModeWithWaitGroupAndCancel
+}
+
+func (i *Implementator) withWaitGroup() bool {
+	return i.mode == ModeWithWaitGroup || i.mode == ModeWithWaitGroupAndCancel
 }
 
 func (i *Implementator) Error() error {
@@ -41,16 +80,43 @@ func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
 	switch typeSpec := node.(type) {
 	case *ast.TypeSpec:
 		i.interfaceName = typeSpec.Name.Name
-		decls = append(decls, code.Struct(
-			"Semaphore",
+
+		fields := []code.StructField{
 			code.FieldFromTypeSpec(typeSpec, i.packageName),
-			code.StructField{
+			{
 				Name:    "c",
 				TypeStr: "chan struct{}",
 			},
-		))
+		}
+
+		if i.withWaitGroup() {
+			fields = append(fields,
+				code.StructField{
+					Name:    "mu",
+					TypeStr: "sync.RWMutex",
+				},
+				code.StructField{
+					Name:    "closed",
+					TypeStr: "bool",
+				},
+				code.StructField{
+					Name:    "wg",
+					TypeStr: "sync.WaitGroup",
+				},
+			)
+			decls = append(decls, text.ToDecl(
+				`var ErrShutdown = errors.New("semaphore is shut down")`,
+			))
+		}
+
+		decls = append(decls, code.Struct("Semaphore", fields...))
 		decls = append(decls, i.newWraperFunction())
 
+		if i.withWaitGroup() {
+			decls = append(decls, admitFunction())
+			decls = append(decls, shutdownFunction())
+		}
+
 		switch interfaceNode := typeSpec.Type.(type) {
 		case *ast.InterfaceType:
 			for _, methodDef := range interfaceNode.Methods.List {
@@ -81,56 +147,163 @@ func (i *Implementator) newWraperFunction() ast.Decl {
 	return text.ToDecl(template)
 }
 
+func admitFunction() ast.Decl {
+	return text.ToDecl(`
+func (s *Semaphore) admit() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return false
+	}
+
+	s.wg.Add(1)
+
+	return true
+}`)
+}
+
+func shutdownFunction() ast.Decl {
+	return text.ToDecl(`
+func (s *Semaphore) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}`)
+}
+
 func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
-	takesContext := code.IsContext(field.Type.(*ast.FuncType).Params.List[0].Type)
+	if i.cancelable() {
+		validateCancelable(field)
+	}
+
+	params := field.Type.(*ast.FuncType).Params.List
+	takesContext := len(params) > 0 && code.IsContext(params[0].Type)
 
 	results := code.AddPackageNameToFieldListAndRemoveNames(
 		field.Type.(*ast.FuncType).Results,
 		i.packageName,
 	)
 
-	commonArgs := map[string]any{
+	args := code.AddPackageNameToFieldList(field.Type.(*ast.FuncType).Params, i.packageName)
+	varArgs := naming.ExtractFuncArgs(field)
+
+	callArgs := map[string]any{
 		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
 		"fnName":      field.Names[0].Name,
-		"args":        field.Type.(*ast.FuncType).Params,
-		"varArgs":     naming.ExtractFuncArgs(field),
-		"results":     results,
+		"varArgs":     naming.CallArgs(field, varArgs),
 	}
 
-	var t string
-	if takesContext {
-		var zeroReturns []ast.Expr
-		for _, r := range field.Type.(*ast.FuncType).Results.List {
-			zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
-		}
+	var call string
+	if results != nil {
+		call = fstr.Sprintf(callArgs, "return s.{{firstLetter}}.{{fnName}}({{varArgs}})")
+	} else {
+		call = fstr.Sprintf(callArgs, "s.{{firstLetter}}.{{fnName}}({{varArgs}})")
+	}
 
-		returnsError, errorPos := code.DoesFieldReturnError(field)
-		if returnsError {
-			zeroReturns[errorPos] = text.ToExpr("ctx.Err()")
+	var admit string
+	if i.withWaitGroup() {
+		// calls that can't return the error would be dropped silently,
+		// they panic with it instead
+		reject := "panic(ErrShutdown)"
+		if returnsError, _ := code.DoesFieldListReturnError(results); returnsError {
+			reject = fstr.Sprintf(map[string]any{
+				"shutdownReturns": returnsWithError(results, text.ToExpr("ErrShutdown")),
+			}, "return {{shutdownReturns}}")
 		}
 
-		commonArgs["zeroReturns"] = zeroReturns
+		admit = fstr.Sprintf(map[string]any{
+			"reject": reject,
+		}, `
+	if !s.admit() {
+		{{reject}}
+	}
+	defer s.wg.Done()
+`)
+	}
 
-		t = fstr.Sprintf(
-			commonArgs,
-			`
-func (s *Semaphore) {{fnName}}({{args}}) ({{results}}) {
+	var acquire string
+	if takesContext {
+		acquire = fstr.Sprintf(map[string]any{
+			"ctx": varArgs[0],
+			"zeroReturns": returnsWithError(
+				results,
+				text.ToExpr(fmt.Sprintf("%s.Err()", code.NodeToString(varArgs[0]))),
+			),
+			"call": call,
+		}, `
 	select {
 	case s.c <- struct{}{}:
 		defer func() { <-s.c }()
-		return s.{{firstLetter}}.{{fnName}}({{varArgs}})
-	case <-ctx.Done():
+		{{call}}
+	case <-{{ctx}}.Done():
 		return {{zeroReturns}}
-	}
-}`)
+	}`)
 	} else {
-		t = fstr.Sprintf(commonArgs, `
-func (s *Semaphore) {{fnName}}({{args}}) ({{results}}) {
-	s.c <- struct{}{} 
+		acquire = fstr.Sprintf(map[string]any{
+			"call": call,
+		}, `
+	s.c <- struct{}{}
 	defer func() { <-s.c }()
-	return s.{{firstLetter}}.{{fnName}}({{varArgs}})
-}`)
+	{{call}}`)
 	}
 
+	t := fstr.Sprintf(map[string]any{
+		"fnName":  field.Names[0].Name,
+		"args":    args,
+		"results": results,
+		"admit":   admit,
+		"acquire": acquire,
+	}, `
+func (s *Semaphore) {{fnName}}({{args}}) ({{results}}) {
+	{{admit}}
+	{{acquire}}
+}`)
+
 	return text.ToDecl(t)
 }
+
+// returnsWithError returns zero values for results, with err in place of
+// the error if the results end with one
+func returnsWithError(results *ast.FieldList, err ast.Expr) []ast.Expr {
+	if results == nil {
+		return nil
+	}
+
+	zeroReturns := []ast.Expr{}
+	for _, r := range results.List {
+		zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+	}
+
+	returnsError, errorPos := code.DoesFieldListReturnError(results)
+	if returnsError {
+		zeroReturns[errorPos] = err
+	}
+
+	return zeroReturns
+}
+
+func validateCancelable(field *ast.Field) {
+	params := field.Type.(*ast.FuncType).Params.List
+	if len(params) == 0 || !code.IsContext(params[0].Type) {
+		panic("first argument must be a context")
+	}
+
+	returnsError, _ := code.DoesFieldReturnError(field)
+	if !returnsError {
+		panic("last return value must be an error")
+	}
+}
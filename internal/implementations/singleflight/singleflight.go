@@ -0,0 +1,221 @@
+package singleflight
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "singleflight"
+}
+
+func (i *Implementator) Description() string {
+	return "Share result of one call between concurrent identical calls, " +
+		"methods with func, channel or variadic params are forwarded"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			"SingleFlight",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "group",
+				TypeStr: "singleflight.Group",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			deduplicated := 0
+			for _, methodDef := range interfaceNode.Methods.List {
+				if isDeduplicable(methodDef.Type.(*ast.FuncType)) {
+					deduplicated++
+					decls = append(decls, i.implementFunction(methodDef))
+				} else {
+					decls = append(decls, i.forwardFunction(methodDef))
+				}
+			}
+
+			if deduplicated == 0 {
+				panic("expected at least one method with 2 return values, last being an error")
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+// isDeduplicable checks if the method has the shape that cache accepts and
+// if its params can tell calls apart in the key, funcs and channels print as
+// pointers, so calls with different options would share the key
+func isDeduplicable(funcType *ast.FuncType) bool {
+	results := funcType.Results
+	if results == nil || len(results.List) != 2 || !code.IsError(results.List[1].Type) {
+		return false
+	}
+
+	for _, param := range funcType.Params.List {
+		if !keyable(param.Type) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// keyable tells whether the param prints its value in the key, variadic
+// params are usually functional options
+func keyable(t ast.Expr) bool {
+	switch t := t.(type) {
+	case *ast.FuncType, *ast.ChanType, *ast.Ellipsis:
+		return false
+	case *ast.Ident:
+		resolved, ok := code.TypeOf(t)
+		if !ok {
+			return true
+		}
+
+		switch resolved.Underlying().(type) {
+		case *types.Signature, *types.Chan:
+			return false
+		default:
+			return true
+		}
+	default:
+		return true
+	}
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New({{firstLetter}} {{interfaceSelector}}) *SingleFlight {
+		return &SingleFlight{
+			{{firstLetter}}: {{firstLetter}},
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+	resultType := results.List[0].Type
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        params,
+		"results":     results,
+		"varArgs":     naming.CallArgs(field, varArgs),
+		"key":         generateKey(field.Names[0].Name, params, varArgs),
+		"resultType":  resultType,
+		"shared":      naming.LocalName("shared", varArgs),
+		"err":         naming.LocalName("err", varArgs),
+		"value":       naming.LocalName("value", varArgs),
+		"zeroValue":   code.ZeroValue(resultType),
+	}, `
+func (s *SingleFlight) {{fnName}}({{args}}) ({{results}}) {
+	{{shared}}, {{err}}, _ := s.group.Do({{key}}, func() (interface{}, error) {
+		return s.{{firstLetter}}.{{fnName}}({{varArgs}})
+	})
+	if {{err}} != nil {
+		return {{zeroValue}}, {{err}}
+	}
+
+	{{value}}, _ := {{shared}}.({{resultType}})
+
+	return {{value}}, nil
+}`))
+}
+
+func (i *Implementator) forwardFunction(field *ast.Field) ast.Decl {
+	funcType := field.Type.(*ast.FuncType)
+
+	args := map[string]any{
+		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":      field.Names[0].Name,
+		"args":        code.AddPackageNameToFieldList(funcType.Params, i.packageName),
+		"results":     code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName),
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
+	}
+
+	if funcType.Results == nil {
+		return text.ToDecl(fstr.Sprintf(args, `
+func (s *SingleFlight) {{fnName}}({{args}}) ({{results}}) {
+	s.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+	}
+
+	return text.ToDecl(fstr.Sprintf(args, `
+func (s *SingleFlight) {{fnName}}({{args}}) ({{results}}) {
+	return s.{{firstLetter}}.{{fnName}}({{varArgs}})
+}`))
+}
+
+// generateKey builds key out of method name and all params but context,
+// so identical calls of the same method share the key. Params are printed
+// in Go syntax, strings quoted, so separators in them can't make different
+// calls share the key
+func generateKey(fnName string, params *ast.FieldList, varArgs []ast.Expr) string {
+	keyArgs := []string{}
+	for n, arg := range varArgs {
+		if n == 0 && code.IsContext(params.List[0].Type) {
+			continue
+		}
+
+		keyArgs = append(keyArgs, code.NodeToString(arg))
+	}
+
+	if len(keyArgs) == 0 {
+		return fmt.Sprintf("%q", fnName)
+	}
+
+	return fmt.Sprintf(
+		`fmt.Sprintf("%s%s", %s)`,
+		fnName,
+		strings.Repeat("|%#v", len(keyArgs)),
+		strings.Join(keyArgs, ", "),
+	)
+}
@@ -3,28 +3,19 @@ package slog
 import (
 	"fmt"
 	"go/ast"
-	"go/token"
+	"strings"
 	"unicode"
 
 	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
 	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
 )
 
-type slogFunc string
-
-const (
-	slogInfo slogFunc = "Info"
-)
-
-func Visitor(packageName string, fset *token.FileSet) func(node ast.Node) bool {
-	return func(node ast.Node) bool {
-		return true
-	}
-}
-
 type Implementator struct {
-	err         error
-	packageName string
+	err           error
+	packageName   string
+	interfaceName string
 }
 
 func New(sourcePackageName string) *Implementator {
@@ -38,7 +29,7 @@ func (i *Implementator) Name() string {
 }
 
 func (i *Implementator) Description() string {
-	return "Generates slog stdout for a given interface, expect only single return errors in methods."
+	return "Log calls, their arguments, results, duration and error using slog.Logger"
 }
 
 func (i *Implementator) Error() error {
@@ -50,13 +41,29 @@ func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
 
 	switch typeSpec := node.(type) {
 	case *ast.TypeSpec:
-		decls = append(decls, code.Struct(typeSpec.Name.Name))
-		decls = append(decls, newFunction(typeSpec.Name.Name))
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			i.interfaceName,
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "logger",
+				TypeStr: "*slog.Logger",
+			},
+			code.StructField{
+				Name:    "level",
+				TypeStr: "slog.Level",
+			},
+			code.StructField{
+				Name:    "errorLevel",
+				TypeStr: "slog.Level",
+			},
+		))
+		decls = append(decls, i.newWrapperFunction())
 
 		switch interfaceNode := typeSpec.Type.(type) {
 		case *ast.InterfaceType:
 			for _, methodDef := range interfaceNode.Methods.List {
-				decls = append(decls, i.implementFunction(typeSpec.Name.Name, methodDef))
+				decls = append(decls, i.implementFunction(methodDef))
 			}
 		default:
 			panic("not an interface")
@@ -67,151 +74,133 @@ func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
 	return false, decls
 }
 
-func newFunction(interfaceName string) ast.Decl {
-	return &ast.FuncDecl{
-		Name: ast.NewIdent("New" + interfaceName),
-		Type: &ast.FuncType{
-			Params: &ast.FieldList{},
-			Results: &ast.FieldList{
-				List: []*ast.Field{
-					{
-						Type: &ast.StarExpr{
-							X: ast.NewIdent(interfaceName),
-						},
-					},
-				},
-			},
-		},
-		Body: &ast.BlockStmt{
-			List: []ast.Stmt{
-				&ast.ReturnStmt{
-					Results: []ast.Expr{
-						&ast.UnaryExpr{
-							Op: token.AND,
-							X: &ast.CompositeLit{
-								Type: ast.NewIdent(interfaceName),
-							},
-						},
-					},
-				},
-			},
-		},
-	}
+func (i *Implementator) newWrapperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"interfaceName":     i.interfaceName,
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New{{interfaceName}}(
+		{{firstLetter}} {{interfaceSelector}},
+		logger *slog.Logger,
+		level, errorLevel slog.Level,
+	) *{{interfaceName}} {
+		return &{{interfaceName}}{
+			{{firstLetter}}: {{firstLetter}},
+			logger: logger,
+			level: level,
+			errorLevel: errorLevel,
+		}
+	}`)
+
+	return text.ToDecl(template)
 }
 
-func (i *Implementator) implementFunction(interfaceName string, field *ast.Field) ast.Decl {
-	firstLetter := string(unicode.ToLower(rune(interfaceName[0])))
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	firstLetter := string(unicode.ToLower(rune(i.interfaceName[0])))
+	funcType := field.Type.(*ast.FuncType)
 	funcName := field.Names[0].Name
 
-	typeDef := &ast.FuncType{
-		Params: &ast.FieldList{
-			List: field.Type.(*ast.FuncType).Params.List,
-		},
-		Results: &ast.FieldList{},
-	}
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
 
 	callArgs := naming.ExtractFuncArgs(field)
+	resultVars, returnsError := naming.ExtractResultVars(funcType)
+
+	ctx := "context.Background()"
+	argAttrs := []string{}
+	for n, arg := range callArgs {
+		name := code.NodeToString(arg)
+		if n == 0 && code.IsContext(params.List[0].Type) {
+			ctx = name
+			continue
+		}
 
-	if field.Type != nil {
-		switch n := field.Type.(type) {
-		case *ast.FuncType:
-			if n.Results != nil {
-				typeDef.Results.List = append(typeDef.Results.List, n.Results.List...)
-			}
-		default:
-			fmt.Printf("Unknown type: %T\n", n)
-			panic("Unknown type")
+		argAttrs = append(argAttrs, fmt.Sprintf("%q, %s", name, name))
+	}
+
+	// locals and the receiver don't shadow the params
+	resultAttrs := []string{}
+	for n, result := range resultVars {
+		name := code.NodeToString(result)
+		resultVars[n] = ast.NewIdent(naming.LocalName(name, callArgs))
+
+		if name == "err" {
+			continue
 		}
+
+		resultAttrs = append(resultAttrs, fmt.Sprintf("%q, %s", resultVars[n], resultVars[n]))
 	}
 
-	returns := processReturns(typeDef)
+	receiver := naming.LocalName(firstLetter, callArgs)
+	errName := naming.LocalName("err", callArgs)
 
-	interfacePlusMethodName := fmt.Sprintf(
+	logPrefix := fmt.Sprintf(
 		"%s_%s",
-		naming.LowercaseFirstLetter(interfaceName),
+		naming.LowercaseFirstLetter(i.interfaceName),
 		naming.LowercaseFirstLetter(funcName),
 	)
 
-	return &ast.FuncDecl{
-		Recv: &ast.FieldList{
-			List: []*ast.Field{
-				{
-					Names: []*ast.Ident{ast.NewIdent(firstLetter)},
-					Type: &ast.StarExpr{
-						X: ast.NewIdent(interfaceName),
-					},
-				},
-			},
-		},
-		Name: ast.NewIdent(funcName),
-		Type: typeDef,
-		Body: i.stdoutBody(returns, interfacePlusMethodName, callArgs),
+	args := map[string]any{
+		"interfaceName": i.interfaceName,
+		"firstLetter":   firstLetter,
+		"fnName":        funcName,
+		"args":          params,
+		"results":       results,
+		"varArgs":       naming.CallArgs(field, callArgs),
+		"ctx":           ctx,
+		"logPrefix":     logPrefix,
+		"successAttrs":  attrs(append(argAttrs, resultAttrs...)),
+		"receiver":      receiver,
+		"start":         naming.LocalName("start", callArgs),
 	}
-}
 
-func (i *Implementator) stdoutBody(
-	returnExpr []ast.Expr,
-	logPrefix string,
-	funcArgs []ast.Expr,
-) *ast.BlockStmt {
-	slogArgs := []ast.Expr{
-		&ast.BasicLit{
-			Kind:  token.STRING,
-			Value: fmt.Sprintf(`%q`, fmt.Sprintf("%s: ", logPrefix)),
-		},
+	switch {
+	case returnsError:
+		args["resultVars"] = resultVars
+		args["errorAttrs"] = attrs(append(argAttrs, `"error", `+errName))
+		args["err"] = errName
+
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{receiver}} *{{interfaceName}}) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{resultVars}} := {{receiver}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	if {{err}} != nil {
+		{{receiver}}.logger.Log({{ctx}}, {{receiver}}.errorLevel, "{{logPrefix}} failed"{{errorAttrs}}, "duration", time.Since({{start}}))
+		return {{resultVars}}
 	}
 
-	for _, arg := range funcArgs {
-		slogArgs = append(slogArgs, &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: fmt.Sprintf(`%q`, arg),
-		})
-		slogArgs = append(slogArgs, &ast.BasicLit{
-			Kind:  token.STRING,
-			Value: fmt.Sprintf("%s", arg),
-		})
-	}
+	{{receiver}}.logger.Log({{ctx}}, {{receiver}}.level, "{{logPrefix}}"{{successAttrs}}, "duration", time.Since({{start}}))
 
-	blockStmt := &ast.BlockStmt{
-		List: []ast.Stmt{
-			&ast.ExprStmt{
-				X: &ast.CallExpr{
-					Fun: &ast.SelectorExpr{
-						X:   ast.NewIdent("slog"),
-						Sel: ast.NewIdent(string(slogInfo)),
-					},
-					Args: slogArgs,
-				},
-			},
-		},
-	}
+	return {{resultVars}}
+}`))
+	case results != nil:
+		args["resultVars"] = resultVars
 
-	if len(returnExpr) > 0 {
-		blockStmt.List = append(blockStmt.List, &ast.ReturnStmt{
-			Results: returnExpr,
-		})
-	}
-
-	return blockStmt
-}
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{receiver}} *{{interfaceName}}) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{resultVars}} := {{receiver}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	{{receiver}}.logger.Log({{ctx}}, {{receiver}}.level, "{{logPrefix}}"{{successAttrs}}, "duration", time.Since({{start}}))
 
-func processReturns(typeDef *ast.FuncType) []ast.Expr {
-	if len(typeDef.Results.List) == 0 {
-		return []ast.Expr{}
+	return {{resultVars}}
+}`))
+	default:
+		return text.ToDecl(fstr.Sprintf(args, `
+func ({{receiver}} *{{interfaceName}}) {{fnName}}({{args}}) ({{results}}) {
+	{{start}} := time.Now()
+	{{receiver}}.{{firstLetter}}.{{fnName}}({{varArgs}})
+	{{receiver}}.logger.Log({{ctx}}, {{receiver}}.level, "{{logPrefix}}"{{successAttrs}}, "duration", time.Since({{start}}))
+}`))
 	}
+}
 
-	if len(typeDef.Results.List) > 1 {
-		panic("Slog implementation only supports returning single error values")
+// attrs formats key value pairs so they can follow the message in the
+// slog call
+func attrs(pairs []string) string {
+	if len(pairs) == 0 {
+		return ""
 	}
 
-	result := typeDef.Results.List[0]
-	switch n := result.Type.(type) {
-	case *ast.Ident:
-		if n.Name == "error" {
-			return []ast.Expr{ast.NewIdent("nil")}
-		}
-	default:
-		panic("Slog implementation only supports returning error")
-	}
-	return []ast.Expr{}
+	return ", " + strings.Join(pairs, ", ")
 }
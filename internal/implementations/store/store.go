@@ -158,7 +158,7 @@ func (i *Implementator) newFunc() ast.Decl {
 				List: []*ast.Field{
 					{
 						Names: []*ast.Ident{ast.NewIdent("repo")},
-						Type:  ast.NewIdent(i.interfaceName),
+						Type:  ast.NewIdent(i.packageName + "." + i.interfaceName),
 					},
 					{
 						Names: []*ast.Ident{ast.NewIdent("interval")},
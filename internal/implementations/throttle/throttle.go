@@ -141,7 +141,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 	returnPartArgs := map[string]any{
 		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
 		"fnName":      field.Names[0].Name,
-		"varArgs":     naming.ExtractFuncArgs(field),
+		"varArgs":     naming.CallArgs(field, naming.ExtractFuncArgs(field)),
 	}
 
 	var returnPart string
@@ -161,7 +161,7 @@ func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
 	t := fstr.Sprintf(map[string]any{
 		"firstLetter": unicode.ToLower(rune(i.interfaceName[0])),
 		"fnName":      field.Names[0].Name,
-		"args":        field.Type.(*ast.FuncType).Params,
+		"args":        code.AddPackageNameToFieldList(field.Type.(*ast.FuncType).Params, i.packageName),
 		"results":     results,
 		"zeroReturns": zeroReturns,
 		"returnPart":  returnPart,
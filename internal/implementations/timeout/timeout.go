@@ -0,0 +1,195 @@
+package timeout
+
+import (
+	"fmt"
+	"go/ast"
+	"strings"
+	"unicode"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
+	"github.com/relardev/go-pattern-implement/internal/fstr"
+	"github.com/relardev/go-pattern-implement/internal/naming"
+	"github.com/relardev/go-pattern-implement/internal/text"
+)
+
+type Implementator struct {
+	err           error
+	packageName   string
+	interfaceName string
+}
+
+func New(sourcePackageName string) *Implementator {
+	return &Implementator{
+		packageName: sourcePackageName,
+	}
+}
+
+func (i *Implementator) Name() string {
+	return "timeout"
+}
+
+func (i *Implementator) Description() string {
+	return "Stop waiting for calls that take longer than configured timeout, per method"
+}
+
+func (i *Implementator) Error() error {
+	return i.err
+}
+
+func (i *Implementator) Visit(node ast.Node) (bool, []ast.Decl) {
+	decls := []ast.Decl{}
+
+	switch typeSpec := node.(type) {
+	case *ast.TypeSpec:
+		i.interfaceName = typeSpec.Name.Name
+		decls = append(decls, code.Struct(
+			"Timeout",
+			code.FieldFromTypeSpec(typeSpec, i.packageName),
+			code.StructField{
+				Name:    "defaultTimeout",
+				TypeStr: "time.Duration",
+			},
+			code.StructField{
+				Name:    "timeouts",
+				TypeStr: "map[string]time.Duration",
+			},
+		))
+		decls = append(decls, i.newWraperFunction())
+		decls = append(decls, timeoutFunction())
+
+		switch interfaceNode := typeSpec.Type.(type) {
+		case *ast.InterfaceType:
+			for _, methodDef := range interfaceNode.Methods.List {
+				decls = append(decls, i.implementFunction(methodDef))
+			}
+		default:
+			panic("not an interface")
+		}
+	default:
+		return true, nil
+	}
+
+	return false, decls
+}
+
+func (i *Implementator) newWraperFunction() ast.Decl {
+	template := fstr.Sprintf(map[string]any{
+		"firstLetter":       unicode.ToLower(rune(i.interfaceName[0])),
+		"interfaceSelector": fmt.Sprintf("%s.%s", i.packageName, i.interfaceName),
+	}, `
+	func New(
+		{{firstLetter}} {{interfaceSelector}},
+		defaultTimeout time.Duration,
+		timeouts map[string]time.Duration,
+	) *Timeout {
+		return &Timeout{
+			{{firstLetter}}: {{firstLetter}},
+			defaultTimeout: defaultTimeout,
+			timeouts: timeouts,
+		}
+	}`)
+
+	return text.ToDecl(template)
+}
+
+func timeoutFunction() ast.Decl {
+	return text.ToDecl(`
+func (t *Timeout) timeout(method string) time.Duration {
+	if timeout, ok := t.timeouts[method]; ok {
+		return timeout
+	}
+
+	return t.defaultTimeout
+}`)
+}
+
+func (i *Implementator) implementFunction(field *ast.Field) ast.Decl {
+	validate(field)
+
+	funcType := field.Type.(*ast.FuncType)
+
+	params := code.AddPackageNameToFieldList(funcType.Params, i.packageName)
+	results := code.AddPackageNameToFieldListAndRemoveNames(funcType.Results, i.packageName)
+
+	varArgs := naming.ExtractFuncArgs(field)
+	ctx := varArgs[0]
+
+	// locals and the receiver don't shadow the params
+	timeoutCtx := naming.LocalName("timeoutCtx", varArgs)
+
+	callArgs := make([]ast.Expr, len(varArgs))
+	copy(callArgs, varArgs)
+	callArgs[0] = ast.NewIdent(timeoutCtx)
+
+	resultVars, _ := naming.ExtractResultVars(funcType)
+	for n, resultVar := range resultVars {
+		resultVars[n] = ast.NewIdent(naming.LocalName(code.NodeToString(resultVar), varArgs))
+	}
+
+	declarations := []string{}
+	zeroReturns := []ast.Expr{}
+	for n, r := range results.List {
+		declarations = append(declarations, fmt.Sprintf(
+			"%s %s",
+			code.NodeToString(resultVars[n]),
+			code.NodeToString(r.Type),
+		))
+		zeroReturns = append(zeroReturns, code.ZeroValue(r.Type))
+	}
+
+	zeroReturns[len(zeroReturns)-1] = text.ToExpr(fmt.Sprintf(
+		`fmt.Errorf("%s.%s: %%w", %s.Err())`,
+		i.interfaceName,
+		field.Names[0].Name,
+		timeoutCtx,
+	))
+
+	return text.ToDecl(fstr.Sprintf(map[string]any{
+		"firstLetter":  unicode.ToLower(rune(i.interfaceName[0])),
+		"fnName":       field.Names[0].Name,
+		"args":         params,
+		"results":      results,
+		"ctx":          ctx,
+		"callArgs":     naming.CallArgs(field, callArgs),
+		"declarations": strings.Join(declarations, "\n"),
+		"resultVars":   resultVars,
+		"zeroReturns":  zeroReturns,
+		"t":            naming.LocalName("t", varArgs),
+		"timeoutCtx":   timeoutCtx,
+		"cancel":       naming.LocalName("cancel", varArgs),
+		"done":         naming.LocalName("done", varArgs),
+	}, `
+func ({{t}} *Timeout) {{fnName}}({{args}}) ({{results}}) {
+	{{timeoutCtx}}, {{cancel}} := context.WithTimeout({{ctx}}, {{t}}.timeout("{{fnName}}"))
+	defer {{cancel}}()
+
+	var (
+		{{declarations}}
+	)
+
+	{{done}} := make(chan struct{})
+	go func() {
+		{{resultVars}} = {{t}}.{{firstLetter}}.{{fnName}}({{callArgs}})
+		close({{done}})
+	}()
+
+	select {
+	case <-{{done}}:
+		return {{resultVars}}
+	case <-{{timeoutCtx}}.Done():
+		return {{zeroReturns}}
+	}
+}`))
+}
+
+func validate(field *ast.Field) {
+	params := field.Type.(*ast.FuncType).Params.List
+	if len(params) == 0 || !code.IsContext(params[0].Type) {
+		panic("first argument must be a context")
+	}
+
+	returnsError, _ := code.DoesFieldReturnError(field)
+	if !returnsError {
+		panic("last return value must be an error")
+	}
+}
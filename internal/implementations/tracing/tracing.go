@@ -98,9 +98,9 @@ func (i *Implementator) implementFunction(interfaceName string, field *ast.Field
 		"interfaceName": i.interfaceName,
 		"firstLetter":   unicode.ToLower(rune(i.interfaceName[0])),
 		"fnName":        field.Names[0].Name,
-		"args":          field.Type.(*ast.FuncType).Params,
+		"args":          code.AddPackageNameToFieldList(field.Type.(*ast.FuncType).Params, i.packageName),
 		"results":       results,
-		"varArgs":       varArgs,
+		"varArgs":       naming.CallArgs(field, varArgs),
 		"resultVars":    naming.ExtractFuncReturns(field),
 		"traceName":     fmt.Sprintf("%s.%s", interfaceName, field.Names[0].Name),
 	}, `
@@ -6,6 +6,8 @@ import (
 	"strings"
 	"unicode"
 	"unicode/utf8"
+
+	"github.com/relardev/go-pattern-implement/internal/code"
 )
 
 func ExtractFuncArgs(field *ast.Field) []ast.Expr {
@@ -26,6 +28,8 @@ func ExtractFuncArgs(field *ast.Field) []ast.Expr {
 			name = VariableNameFromExpr(n)
 		case *ast.FuncType:
 			name = "fn"
+		case *ast.Ellipsis:
+			name = VariableNameFromExpr(n)
 		default:
 			name = "arg"
 		}
@@ -50,6 +54,45 @@ func ExtractFuncArgs(field *ast.Field) []ast.Expr {
 	return callArgs
 }
 
+// CallArgs prints args of the method, the ones returned by ExtractFuncArgs
+// or replacing them, for a call, variadic param is passed on spread
+func CallArgs(field *ast.Field, args []ast.Expr) string {
+	call := code.NodeToString(args)
+	if IsVariadic(field) && len(args) > 0 {
+		call += "..."
+	}
+
+	return call
+}
+
+// IsVariadic tells whether the last param of the method is variadic
+func IsVariadic(field *ast.Field) bool {
+	params := field.Type.(*ast.FuncType).Params.List
+	if len(params) == 0 {
+		return false
+	}
+
+	_, ok := params[len(params)-1].Type.(*ast.Ellipsis)
+
+	return ok
+}
+
+// LocalName returns name for a local variable, or a receiver, that doesn't
+// shadow any of the params, numbered when the name is taken
+func LocalName(name string, varArgs []ast.Expr) string {
+	taken := map[string]bool{}
+	for _, arg := range varArgs {
+		taken[code.NodeToString(arg)] = true
+	}
+
+	candidate := name
+	for n := 2; taken[candidate]; n++ {
+		candidate = fmt.Sprintf("%s%d", name, n)
+	}
+
+	return candidate
+}
+
 func ExtractFuncReturns(field *ast.Field) []ast.Expr {
 	returns := []ast.Expr{}
 	results := field.Type.(*ast.FuncType).Results
@@ -64,6 +107,59 @@ func ExtractFuncReturns(field *ast.Field) []ast.Expr {
 	return returns
 }
 
+// ExtractResultVars returns variable names for the results of the function,
+// "err" for the error and "result" or "resultN" for the rest, and whether
+// the function returns an error
+func ExtractResultVars(typeDef *ast.FuncType) ([]ast.Expr, bool) {
+	if typeDef.Results == nil {
+		return nil, false
+	}
+
+	resultsList := typeDef.Results.List
+	var returningError bool
+
+	var returns []ast.Expr
+	for _, result := range resultsList {
+		switch n := result.Type.(type) {
+		case *ast.Ident:
+			if n.Name == "error" {
+				returningError = true
+			}
+		}
+	}
+
+	var namedReturns int
+
+	if returningError {
+		namedReturns = len(resultsList) - 1
+	} else {
+		namedReturns = len(resultsList)
+	}
+
+	for n, result := range resultsList {
+		isError := false
+
+		switch n := result.Type.(type) {
+		case *ast.Ident:
+			if n.Name == "error" {
+				isError = true
+			}
+		}
+
+		if isError {
+			returns = append(returns, ast.NewIdent("err"))
+		} else {
+			if namedReturns > 1 {
+				returns = append(returns, ast.NewIdent(fmt.Sprintf("result%v", n+1)))
+			} else {
+				returns = append(returns, ast.NewIdent("result"))
+			}
+		}
+	}
+
+	return returns, returningError
+}
+
 func VariableNameFromExpr(t ast.Expr) string {
 	switch r := t.(type) {
 	case *ast.StarExpr:
@@ -71,6 +167,10 @@ func VariableNameFromExpr(t ast.Expr) string {
 	case *ast.SelectorExpr:
 		return nameFromSelector(r)
 	case *ast.Ident:
+		// type parameter names are too short to name anything after them
+		if code.IsTypeParam(r) {
+			return "value"
+		}
 		if unicode.IsUpper(rune(r.Name[0])) {
 			return VarNameFromType(r.Name)
 		}
@@ -83,10 +183,20 @@ func VariableNameFromExpr(t ast.Expr) string {
 			return "i"
 		case "bool":
 			return "b"
+		case "any":
+			return "thing"
 		case "uint64":
 			return "u64"
+		case "byte":
+			return "b"
+		case "rune":
+			return "r"
+		case "float32", "float64":
+			return "f"
 		default:
-			panic(fmt.Sprintf("Unhandled ident in VariableNameFromExpr: %s", r.Name))
+			// unexported types of the source package get qualified, so
+			// the name doesn't clash with the type
+			return r.Name
 		}
 	case *ast.ArrayType:
 		name := VariableNameFromExpr(r.Elt)
@@ -100,9 +210,21 @@ func VariableNameFromExpr(t ast.Expr) string {
 			name += "s"
 		}
 		return name
+	case *ast.Ellipsis:
+		return VariableNameFromExpr(&ast.ArrayType{Elt: r.Elt})
+	case *ast.IndexExpr:
+		return VariableNameFromExpr(r.X)
+	case *ast.IndexListExpr:
+		return VariableNameFromExpr(r.X)
 
-	case *ast.InterfaceType:
+	case *ast.ChanType:
+		return "ch"
+	case *ast.FuncType:
+		return "fn"
+	case *ast.InterfaceType, *ast.StructType:
 		return "thing"
+	case *ast.ParenExpr:
+		return VariableNameFromExpr(r.X)
 	default:
 		panic(fmt.Sprintf("Unknown type in VariableNameFromExpr: %T", r))
 	}
@@ -118,6 +240,14 @@ func LowercaseFirstLetter(s string) string {
 	return strings.ToLower(string(r)) + s[size:]
 }
 
+func UppercaseFirstLetter(s string) string {
+	if s == "" {
+		return ""
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	return strings.ToUpper(string(r)) + s[size:]
+}
+
 func VarNameFromType(s string) string {
 	return LowercaseFirstLetter(s)
 	// TODO lowercase everything untill upper followed by lower found
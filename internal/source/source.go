@@ -0,0 +1,172 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Interface is the type to implement loaded from a package
+type Interface struct {
+	// Input holds imports and declaration of the type followed by
+	// interfaces of the same package it embeds, in the form the generator
+	// takes from stdin
+	Input       string
+	PackageName string
+	ImportPath  string
+	// Dir is the directory of the package
+	Dir string
+}
+
+// Load finds the type declaration in the package, source is either
+// a directory or an import path resolved from the current module. Nothing
+// gets downloaded, the package has to be available locally.
+func Load(source, typeName string) (*Interface, error) {
+	cfg := &packages.Config{
+		Mode: packages.NeedName | packages.NeedFiles | packages.NeedSyntax,
+		Env:  append(os.Environ(), "GOPROXY=off"),
+	}
+
+	pattern := source
+	if info, err := os.Stat(source); err == nil && info.IsDir() {
+		cfg.Dir = source
+		pattern = "."
+	}
+
+	pkgs, err := packages.Load(cfg, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("loading %s: %w", source, err)
+	}
+
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected exactly one package in %s, found %d", source, len(pkgs))
+	}
+
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		errs := []error{}
+		for _, pkgErr := range pkg.Errors {
+			errs = append(errs, pkgErr)
+		}
+
+		return nil, fmt.Errorf("loading %s: %w", source, errors.Join(errs...))
+	}
+
+	typeSpecs := map[string]*ast.TypeSpec{}
+	files := map[*ast.TypeSpec]*ast.File{}
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				typeSpecs[typeSpec.Name.Name] = typeSpec
+				files[typeSpec] = file
+			}
+		}
+	}
+
+	typeSpec, ok := typeSpecs[typeName]
+	if !ok {
+		return nil, fmt.Errorf("type %s not found in package %s", typeName, pkg.PkgPath)
+	}
+
+	// imports say where types of other packages come from
+	imports := []string{}
+	seenImports := map[string]bool{}
+	decls := []string{}
+	for _, spec := range withEmbedded(typeSpec, typeSpecs) {
+		for _, importSpec := range files[spec].Imports {
+			line, err := printNode(pkg.Fset, importSpec)
+			if err != nil {
+				return nil, err
+			}
+
+			if !seenImports[line] {
+				seenImports[line] = true
+				imports = append(imports, "\t"+line)
+			}
+		}
+
+		decl, err := printNode(pkg.Fset, spec)
+		if err != nil {
+			return nil, err
+		}
+
+		decls = append(decls, "type "+decl)
+	}
+
+	if len(imports) > 0 {
+		decls = append([]string{"import (\n" + strings.Join(imports, "\n") + "\n)"}, decls...)
+	}
+
+	return &Interface{
+		Input:       strings.Join(decls, "\n\n"),
+		PackageName: pkg.Name,
+		ImportPath:  pkg.PkgPath,
+		Dir:         filepath.Dir(pkg.GoFiles[0]),
+	}, nil
+}
+
+// withEmbedded returns the type spec and, for interfaces, specs of
+// interfaces of the same package it embeds, directly or not
+func withEmbedded(typeSpec *ast.TypeSpec, typeSpecs map[string]*ast.TypeSpec) []*ast.TypeSpec {
+	result := []*ast.TypeSpec{}
+	seen := map[string]bool{}
+
+	var add func(*ast.TypeSpec)
+	add = func(spec *ast.TypeSpec) {
+		if seen[spec.Name.Name] {
+			return
+		}
+
+		seen[spec.Name.Name] = true
+		result = append(result, spec)
+
+		interfaceType, ok := spec.Type.(*ast.InterfaceType)
+		if !ok {
+			return
+		}
+
+		for _, field := range interfaceType.Methods.List {
+			if len(field.Names) > 0 {
+				continue
+			}
+
+			embedded := field.Type
+			switch e := embedded.(type) {
+			case *ast.IndexExpr:
+				embedded = e.X
+			case *ast.IndexListExpr:
+				embedded = e.X
+			}
+
+			if ident, ok := embedded.(*ast.Ident); ok && typeSpecs[ident.Name] != nil {
+				add(typeSpecs[ident.Name])
+			}
+		}
+	}
+
+	add(typeSpec)
+
+	return result
+}
+
+func printNode(fset *token.FileSet, node any) (string, error) {
+	var output strings.Builder
+	if err := printer.Fprint(&output, fset, node); err != nil {
+		return "", err
+	}
+
+	return output.String(), nil
+}
@@ -0,0 +1,36 @@
+#!/bin/bash
+
+# check command is run against files generated in the app, as they are
+# and after the interface they implement changed
+
+app=test/check/testdata/app
+bin=$(pwd)/bin/go-pattern-implement
+
+run_check() {
+    (cd $app && $bin check . --manifest wrappers.txt 2>&1; echo "exit status $?")
+}
+
+compare() {
+    result="test/check/$1/result"
+    expected="test/check/$1/expected"
+
+    diff_output=$(diff "$result" "$expected")
+
+    if [ $? -ne 0 ]; then
+        echo "result is different from expected: $result vs $expected"
+        echo "$diff_output"
+        exit 1
+    fi
+}
+
+echo "Testing check, with test: up-to-date"
+run_check > test/check/up-to-date/result
+compare up-to-date
+
+echo "Testing check, with test: drift"
+mv $app/repo/repo.go $app/repo/repo.go.orig
+trap 'mv $app/repo/repo.go.orig $app/repo/repo.go' EXIT
+sed 's/^	Save(ctx context.Context, user User) error$/&\
+	Delete(ctx context.Context, id string) error/' $app/repo/repo.go.orig > $app/repo/repo.go
+run_check > test/check/drift/result
+compare drift
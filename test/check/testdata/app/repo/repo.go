@@ -0,0 +1,15 @@
+package repo
+
+import "context"
+
+type User struct {
+	ID   string
+	Name string
+}
+
+type Repo interface {
+	Get(ctx context.Context, id string) (User, error)
+	Save(ctx context.Context, user User) error
+}
+
+//go:generate go-pattern-implement implement mock --source . --type Repo --out repo_mock.go
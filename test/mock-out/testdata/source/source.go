@@ -0,0 +1,16 @@
+package source
+
+import (
+	"context"
+	"io"
+)
+
+type User struct {
+	ID string
+}
+
+type Repo interface {
+	io.Closer
+	Get(ctx context.Context, id string) (User, error)
+	Save(ctx context.Context, user User) error
+}
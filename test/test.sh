@@ -2,8 +2,13 @@
 
 tests='
 prometheus
+prometheus:prometheus-variadic
 cache
 semaphore
+semaphore-cancel
+semaphore-waitgroup
+semaphore-waitgroup:semaphore-waitgroup-noerror
+semaphore-waitgroup-cancel
 throttle-error
 throttle
 filter 
@@ -12,6 +17,42 @@ filter-return:filter-return-list
 filter-return:filter-return-map
 filter-param
 tracing
+retry
+retry:retry-same-results
+renew
+renew:renew-time
+batch
+batch:batch-noncomparable
+parallel
+parallel:parallel-names
+log
+log:log-names
+slog
+slog:slog-names
+circuit-breaker
+circuit-breaker:circuit-breaker-update
+timeout
+timeout:timeout-names
+singleflight
+singleflight:singleflight-names
+singleflight:singleflight-options
+fallback
+fallback:fallback-generic
+mock
+mock:mock-variadic
+mock:mock-names
+mock:mock-generic
+mock:mock-embedded
+mock:mock-constraint
+mock:mock-qualify
+mock:mock-file
+mock:mock-file-versions
+mock:mock-update
+mock:mock-out
+recorder
+recorder:recorder-unencodable
+recover
+errwrap
 '
 
 for test in $tests; do
@@ -27,7 +68,23 @@ for test in $tests; do
 
     rm -f test/$test_dir/result
 
-    cat test/$test_dir/input | ./bin/go-pattern-implement implement --package abc $implementation > test/$test_dir/result
+    # optional extra flags for the implement command
+    args=""
+    if [ -f test/$test_dir/args ]; then
+        args=$(cat test/$test_dir/args)
+    fi
+
+    if echo "$args" | grep -q -- "--source"; then
+        # the interface is loaded from the package, the file is written
+        # with --out and records the command in its header
+        ./bin/go-pattern-implement implement $args --out test/$test_dir/result $implementation
+    elif [ -f test/$test_dir/existing ]; then
+        # the file written before is updated in place
+        cp test/$test_dir/existing test/$test_dir/result
+        cat test/$test_dir/input | ./bin/go-pattern-implement implement --package abc $args --update test/$test_dir/result $implementation
+    else
+        cat test/$test_dir/input | ./bin/go-pattern-implement implement --package abc $args $implementation > test/$test_dir/result
+    fi
 
     result="test/$test_dir/result"
     expected="test/$test_dir/expected"